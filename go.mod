@@ -4,6 +4,7 @@ go 1.19
 
 require (
 	github.com/fatih/color v1.13.0
+	github.com/klauspost/compress v1.17.0
 	go.uber.org/zap v1.24.0
 )
 
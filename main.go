@@ -1,62 +1,247 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/dpanic/fuzzer/src/fuzzer"
+	"github.com/dpanic/fuzzer/src/request"
 )
 
+// stringList is flag which can be defined multiple times
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func main() {
 	maxTime := flag.Int("maxTime", 0, "maximum execution time")
+	threads := flag.Int("t", 0, "number of workers, default 4 per CPU, minimum 32")
+	timeout := flag.Int("timeout", 20, "request timeout in seconds")
+	dialTimeout := flag.Int("dialTimeout", 10, "TCP connect timeout in seconds")
+	tlsTimeout := flag.Int("tlsTimeout", 5, "TLS handshake timeout in seconds")
+	maxReadSize := flag.Int("maxReadSize", 1<<20, "maximum number of bytes read from response body, larger bodies are truncated")
 	maxReqSec := flag.Int("maxReqSec", 0, "maximum requests per second, default unlimited")
-	method := flag.String("X", "GET", "GET, POST, HEAD, OPTIONS, PUT ...")
-	filterCodes := flag.String("fc", "", "403,404")
-	filterLines := flag.String("fl", "", "123,321")
-	filterWords := flag.String("fw", "", "1,2,3")
-	filterSize := flag.String("fs", "", "300,200")
+	retries := flag.Int("retries", 0, "retries on timeouts, connection resets, 502 and 504")
+	failedFile := flag.String("failed", "", "/tmp/failed.txt, words of permanently failed requests")
+	ignoreRateLimit := flag.Bool("ignore429", false, "don't pause on 429 and 503 with Retry-After responses")
+	method := flag.String("X", "GET", "GET, POST, HEAD, OPTIONS, PUT ... or FUZZ")
+	body := flag.String("d", "", `request body, {"id": "FUZZ"}`)
+	var headers, cookies stringList
+	flag.Var(&headers, "H", `"X-Custom: FUZZ", can be repeated`)
+	flag.Var(&cookies, "b", `"session=FUZZ", can be repeated`)
+	basicAuth := flag.String("basic", "", "basic auth credentials, user:password")
+	bearerToken := flag.String("bearer", "", "bearer token sent in Authorization header")
+	filterCodes := flag.String("fc", "", "403,404 or 5xx")
+	filterLines := flag.String("fl", "", "123,321 or <5")
+	filterWords := flag.String("fw", "", "1,2,3 or 10-20")
+	filterSize := flag.String("fs", "", "300,200 or 1000-1200,>10000")
+	filterRegex := flag.String("fr", "", "Page Not Found")
+	var filterHeaders, matchHeaders stringList
+	flag.Var(&filterHeaders, "fh", `"Server: nginx", regex on response header, can be repeated`)
+	flag.Var(&matchHeaders, "mh", `"Content-Type: json", regex on response header, can be repeated`)
+	filterMode := flag.String("fmode", fuzzer.CombineOr, "or, and")
+	matchCodes := flag.String("mc", "", "200,301 or 2xx")
+	matchLines := flag.String("ml", "", "123,321 or >5")
+	matchWords := flag.String("mw", "", "1,2,3 or 10-20")
+	matchSize := flag.String("ms", "", "300,200 or <1000")
+	matchRegex := flag.String("mr", "", "admin|dashboard")
+	matcherMode := flag.String("mmode", fuzzer.CombineOr, "or, and")
+	autoCalibrate := flag.Bool("ac", false, "auto calibrate filters for soft 404 and wildcard responses")
+	calibrationTolerance := flag.Int("act", 0, "auto calibration tolerance for size of body")
 	userAgent := flag.String("ua", "", "custom user agent")
 	pseudoRandomUserAgent := flag.Bool("prua", false, "pseudo random user agent")
 
 	outFile := flag.String("o", "", "/tmp/outFile.json")
-	wordList := flag.String("w", "", "wordlists/big.txt")
+	stateFile := flag.String("state", "", "/tmp/state.json, periodically save state of fuzzer")
+	resumeFile := flag.String("resume", "", "/tmp/state.json, resume fuzzer from state file")
+	var wordLists stringList
+	flag.Var(&wordLists, "w", "wordlists/big.txt, wordlists/users.txt.gz:USER, - for stdin or generator range(1,1000,1,4):ID, charset(abc,1,3), date(2024-01-01,2024-12-31,20060102), uuid1(sample,10s,1ms), can be repeated")
+	mode := flag.String("mode", fuzzer.ModeClusterbomb, "clusterbomb, pitchfork")
+	extensions := flag.String("e", "", ".php,.bak,.zip, appended to every word of first word list")
+	extensionsOnly := flag.Bool("eonly", false, "request only words with extensions, without bare word")
+	var encoders stringList
+	flag.Var(&encoders, "enc", `"FUZZ:urlencode,base64" or "FUZZ:prefix:../", encoders applied to words of keyword, can be repeated`)
+	ruleFile := flag.String("rules", "", "rules/best.rule, hashcat style rules applied to every word of first word list")
+	recursion := flag.Bool("recursion", false, "scan found directories, URL must end with FUZZ")
+	recursionDepth := flag.Int("recursionDepth", fuzzer.DefaultRecursionDepth, "maximum depth of recursion")
 	url := flag.String("u", "", "https://www.google.com/FUZZ")
+	vhost := flag.String("vhost", "", "FUZZ.example.com, fuzz Host header against -u, default virtual host is filtered")
+	params := flag.Bool("params", false, "discover hidden parameters of -u, words are parameter names")
+	paramsBatchSize := flag.Int("paramsBatchSize", fuzzer.DefaultParamsBatchSize, "number of parameters sent in one request")
+	paramsLocation := flag.String("paramsLocation", fuzzer.ParamsLocationQuery, "query, body")
+	dns := flag.String("dns", "", "FUZZ.example.com or example.com, enumerate subdomains by resolving names instead of sending HTTP requests")
+	resolvers := flag.String("resolvers", "", "1.1.1.1,8.8.8.8:53, DNS servers used round-robin for -dns and HTTP requests")
+	resolverProtocol := flag.String("resolverProtocol", request.ResolverProtocolUDP, "udp, tcp")
+	requestFile := flag.String("request", "", "req.txt, raw HTTP request with FUZZ used instead of -u, -X and -d")
+	requestScheme := flag.String("requestScheme", fuzzer.DefaultRequestScheme, "http, https, scheme of raw HTTP request")
+	requestHost := flag.String("requestHost", "", "example.com:8443, overrides Host header of raw HTTP request")
 	proxyURL := flag.String("p", "", "http://127.0.0.1:9000")
 
 	flag.Parse()
 
+	if *resumeFile != "" {
+		*stateFile = *resumeFile
+	}
+
+	lists := make([]fuzzer.WordList, 0, len(wordLists))
+	for _, w := range wordLists {
+		lists = append(lists, fuzzer.ParseWordList(w))
+	}
+
+	keywordEncoders := make(map[string][]string, len(encoders))
+	for _, e := range encoders {
+		keyword, chain, err := fuzzer.ParseEncoders(e)
+		if err != nil {
+			fmt.Println(err)
+			flag.PrintDefaults()
+			os.Exit(1)
+		}
+
+		keywordEncoders[keyword] = append(keywordEncoders[keyword], chain...)
+	}
+
+	scanType := fuzzer.ScanTypeHTTP
+	scanTypes := 0
+	if *vhost != "" {
+		scanType = fuzzer.ScanTypeVHost
+		scanTypes++
+	}
+
+	if *params {
+		scanType = fuzzer.ScanTypeParams
+		scanTypes++
+	}
+
+	if *dns != "" {
+		scanType = fuzzer.ScanTypeDNS
+		scanTypes++
+	}
+
+	if scanTypes > 1 {
+		fmt.Println("-vhost, -params and -dns can't be used together")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	customHeaders := http.Header{}
+	for _, h := range headers {
+		name, value, err := request.ParseHeader(h)
+		if err != nil {
+			fmt.Println(err)
+			flag.PrintDefaults()
+			os.Exit(1)
+		}
+
+		// don't canonicalize name, it can contain keyword
+		customHeaders[name] = append(customHeaders[name], value)
+	}
+
+	headerRegexes := func(input stringList) map[string]string {
+		res := make(map[string]string, len(input))
+		for _, h := range input {
+			name, value, err := request.ParseHeader(h)
+			if err != nil {
+				fmt.Println(err)
+				flag.PrintDefaults()
+				os.Exit(1)
+			}
+
+			res[name] = value
+		}
+		return res
+	}
+
 	f, err := fuzzer.New(&fuzzer.Config{
 		URL:                   *url,
 		Method:                *method,
+		ScanType:              scanType,
+		VHost:                 *vhost,
+		ParamsBatchSize:       *paramsBatchSize,
+		ParamsLocation:        *paramsLocation,
+		Domain:                *dns,
+		Resolvers:             request.ParseResolvers(*resolvers),
+		ResolverProtocol:      *resolverProtocol,
+		RequestFile:           *requestFile,
+		RequestScheme:         *requestScheme,
+		RequestHost:           *requestHost,
+		Body:                  *body,
+		Headers:               customHeaders,
+		Cookies:               cookies,
+		BasicAuth:             *basicAuth,
+		BearerToken:           *bearerToken,
 		ProxyURL:              *proxyURL,
 		OutFile:               *outFile,
-		WordList:              *wordList,
+		StateFile:             *stateFile,
+		ResumeFromState:       *resumeFile != "",
+		WordLists:             lists,
+		Mode:                  *mode,
+		Extensions:            fuzzer.ParseExtensions(*extensions),
+		ExtensionsOnly:        *extensionsOnly,
+		RuleFile:              *ruleFile,
+		Encoders:              keywordEncoders,
+		Recursion:             *recursion,
+		RecursionDepth:        *recursionDepth,
 		UserAgent:             *userAgent,
 		PseudoRandomUserAgent: *pseudoRandomUserAgent,
 		MaxTime:               time.Duration(*maxTime) * time.Second,
 		MaxReqSec:             *maxReqSec,
+		Threads:               *threads,
+		Timeout:               time.Duration(*timeout) * time.Second,
+		DialTimeout:           time.Duration(*dialTimeout) * time.Second,
+		TLSHandshakeTimeout:   time.Duration(*tlsTimeout) * time.Second,
+		MaxReadSize:           *maxReadSize,
+		IgnoreRateLimit:       *ignoreRateLimit,
+		Retries:               *retries,
+		FailedFile:            *failedFile,
 		Filters: fuzzer.Filters{
-			StatusCodes: fuzzer.GetUniqueNumbers(*filterCodes, ","),
-			Words:       fuzzer.GetUniqueNumbers(*filterWords, ","),
-			Lines:       fuzzer.GetUniqueNumbers(*filterLines, ","),
-			Size:        fuzzer.GetUniqueNumbers(*filterSize, ","),
+			StatusCodes: *filterCodes,
+			Words:       *filterWords,
+			Lines:       *filterLines,
+			Size:        *filterSize,
+			Regex:       *filterRegex,
+			Headers:     headerRegexes(filterHeaders),
+		},
+		FilterMode: *filterMode,
+		Matchers: fuzzer.Matchers{
+			StatusCodes: *matchCodes,
+			Words:       *matchWords,
+			Lines:       *matchLines,
+			Size:        *matchSize,
+			Regex:       *matchRegex,
+			Headers:     headerRegexes(matchHeaders),
 		},
+		MatcherMode:          *matcherMode,
+		AutoCalibrate:        *autoCalibrate,
+		CalibrationTolerance: *calibrationTolerance,
 	})
 
 	if err != nil {
+		fmt.Println(err)
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	go f.Start()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, pauseSignals...)
 	signal.Notify(signalChannel,
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT,
@@ -68,20 +253,26 @@ func main() {
 			f.Log.Warn(fmt.Sprintf("received signal %s", s.String()))
 
 			switch s {
-			case syscall.SIGHUP:
-			case syscall.SIGINT:
-				f.Done <- "SIGINT"
-				return
-			case syscall.SIGTERM:
-				f.Done <- "SIGTERM"
-				return
-			case syscall.SIGQUIT:
-				f.Done <- "SIGQUIT"
+			case syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT:
+				cancel()
 				return
+			default:
+				if f.IsPaused() {
+					f.Resume()
+				} else {
+					f.Pause()
+				}
 			}
 		}
 	}()
 
-	<-f.Done
-	f.Stop()
+	err = f.Run(ctx)
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+	case errors.Is(err, fuzzer.ErrMaxRuntime):
+		f.Log.Warn(err.Error())
+	default:
+		f.Log.Error(err.Error())
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// pauseSignals toggle pause of fuzzer
+var pauseSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGUSR1,
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// pauseSignals toggle pause of fuzzer, there is no SIGUSR1 on windows
+var pauseSignals = []os.Signal{
+	syscall.SIGHUP,
+}
@@ -0,0 +1,173 @@
+package fuzzer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultCalibrationRequests is number of random paths requested during calibration
+	defaultCalibrationRequests = 4
+
+	// calibrationTokenSize is number of random bytes used for non existent path
+	calibrationTokenSize = 12
+)
+
+// fingerprint describes response of non existent resource learned during calibration
+type fingerprint struct {
+	StatusCode int         `json:"statusCode"`
+	Size       numberRange `json:"size"`
+	Words      numberRange `json:"words"`
+	Lines      numberRange `json:"lines"`
+}
+
+func (fp *fingerprint) add(r *response) {
+	fp.Size = extendRange(fp.Size, r.Size)
+	fp.Words = extendRange(fp.Words, r.Words)
+	fp.Lines = extendRange(fp.Lines, r.Lines)
+}
+
+// widen widens size range by tolerance, since size of soft 404 pages usually
+// depends on length of reflected path
+func (fp *fingerprint) widen(tolerance int) {
+	fp.Size.Min -= tolerance
+	if fp.Size.Min < 0 {
+		fp.Size.Min = 0
+	}
+	fp.Size.Max += tolerance
+}
+
+// ignoreSize matches fingerprint of any size, size of vhost and params scan
+// responses changes with reflected host and parameter names
+func (fp *fingerprint) ignoreSize() {
+	fp.Size = numberRange{Min: 0, Max: math.MaxInt32}
+}
+
+func (fp *fingerprint) matches(r *response) bool {
+	return fp.StatusCode == r.StatusCode &&
+		numbers{fp.Size}.contains(r.Size) &&
+		numbers{fp.Words}.contains(r.Words) &&
+		numbers{fp.Lines}.contains(r.Lines)
+}
+
+func (fp fingerprint) String() string {
+	return fmt.Sprintf("status %d, size %d-%d, words %d-%d, lines %d-%d",
+		fp.StatusCode,
+		fp.Size.Min, fp.Size.Max,
+		fp.Words.Min, fp.Words.Max,
+		fp.Lines.Min, fp.Lines.Max,
+	)
+}
+
+func extendRange(r numberRange, value int) numberRange {
+	if value < r.Min {
+		r.Min = value
+	}
+
+	if value > r.Max {
+		r.Max = value
+	}
+
+	return r
+}
+
+func randomToken() string {
+	buf := make([]byte, calibrationTokenSize)
+	rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
+
+// calibrate requests random non existent resources and learns their fingerprints,
+// responses matching learned fingerprints are filtered out
+func (f *Fuzzer) calibrate(ctx context.Context, log *zap.Logger) (err error) {
+	fingerprints := make(map[int]*fingerprint)
+	order := make([]int, 0)
+
+	// vhost scan requests target with its own host too
+	requests := f.CalibrationRequests
+	if f.ScanType == ScanTypeVHost {
+		requests++
+	}
+
+	for i := 0; i < requests; i++ {
+		input := make(map[string]string, len(f.keywords))
+		for _, keyword := range f.keywords {
+			input[keyword] = randomToken()
+		}
+
+		// cycle through variants, since extensions can be handled differently
+		j := f.newJob(f.rootScan(), input, f.variants[i%len(f.variants)])
+		if i == f.CalibrationRequests {
+			j.Headers.Del("Host")
+		}
+
+		if f.ScanType == ScanTypeParams {
+			j = f.newParamsJob(f.calibrationParams(i))
+		}
+
+		r, err := f.execute(ctx, j)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			log.Warn("error in calibration request",
+				zap.String("url", j.URL),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		fp, ok := fingerprints[r.StatusCode]
+		if !ok {
+			fp = &fingerprint{
+				StatusCode: r.StatusCode,
+				Size:       numberRange{Min: r.Size, Max: r.Size},
+				Words:      numberRange{Min: r.Words, Max: r.Words},
+				Lines:      numberRange{Min: r.Lines, Max: r.Lines},
+			}
+			fingerprints[r.StatusCode] = fp
+			order = append(order, r.StatusCode)
+		}
+		fp.add(r)
+	}
+
+	if len(fingerprints) == 0 {
+		err = fmt.Errorf("all %d calibration requests failed", requests)
+		return
+	}
+
+	f.calibration = make([]fingerprint, 0, len(fingerprints))
+	for _, statusCode := range order {
+		fp := fingerprints[statusCode]
+		fp.widen(f.CalibrationTolerance)
+		if f.ScanType == ScanTypeVHost || f.ScanType == ScanTypeParams {
+			fp.ignoreSize()
+		}
+
+		log.Info("calibration learned fingerprint",
+			zap.String("fingerprint", fp.String()),
+		)
+		f.calibration = append(f.calibration, *fp)
+	}
+
+	return
+}
+
+// isCalibrated checks if response matches any of fingerprints learned in calibration
+func (f *Fuzzer) isCalibrated(r *response) bool {
+	for i := range f.calibration {
+		if f.calibration[i].matches(r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,215 @@
+package fuzzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// checkpoint is state of interrupted fuzzer, from which it can be resumed
+type checkpoint struct {
+	// ConfigHash is hash of config which defines jobs, resume is allowed only
+	// with the same config
+	ConfigHash string `json:"configHash"`
+
+	// Position is number of jobs, from the start, which are all processed
+	Position int `json:"position"`
+
+	// Processed are jobs after Position which are processed too, they are
+	// skipped when resumed, so their results aren't saved twice
+	Processed []int `json:"processed,omitempty"`
+
+	Stats       stats         `json:"stats"`
+	Calibration []fingerprint `json:"calibration"`
+
+	// Scans are scans scheduled by recursion, in order
+	Scans []scan `json:"scans,omitempty"`
+
+	Updated time.Time `json:"updated"`
+}
+
+// progress tracks processed jobs, which are processed out of order by workers,
+// and calculates position until which all jobs are processed
+type progress struct {
+	mutex *sync.Mutex
+
+	// position is index of first job which is not processed
+	position int
+
+	// processed holds processed jobs after position
+	processed map[int]bool
+}
+
+func newProgress(position int, processed []int) *progress {
+	p := &progress{
+		mutex:     &sync.Mutex{},
+		position:  position,
+		processed: make(map[int]bool, len(processed)),
+	}
+
+	for _, index := range processed {
+		p.processed[index] = true
+	}
+
+	return p
+}
+
+func (p *progress) done(index int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.processed[index] = true
+	for p.processed[p.position] {
+		delete(p.processed, p.position)
+		p.position++
+	}
+}
+
+// get returns position and sorted jobs after position which are processed
+func (p *progress) get() (position int, processed []int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	position = p.position
+	processed = make([]int, 0, len(p.processed))
+	for index := range p.processed {
+		processed = append(processed, index)
+	}
+	sort.Ints(processed)
+
+	return
+}
+
+// configHash calculates hash of config fields which define generated jobs
+func (f *Fuzzer) configHash() string {
+	raw, _ := json.Marshal(struct {
+		URL         string      `json:"url"`
+		Method      string      `json:"method"`
+		Headers     http.Header `json:"headers"`
+		Cookies     []string    `json:"cookies"`
+		BasicAuth   string      `json:"basicAuth"`
+		BearerToken string      `json:"bearerToken"`
+		Body        string      `json:"body"`
+		WordLists   []WordList  `json:"wordLists"`
+		Mode        string      `json:"mode"`
+		ScanType    string      `json:"scanType"`
+
+		ParamsBatchSize int    `json:"paramsBatchSize"`
+		ParamsLocation  string `json:"paramsLocation"`
+		Domain          string `json:"domain"`
+
+		Extensions     []string            `json:"extensions"`
+		ExtensionsOnly bool                `json:"extensionsOnly"`
+		Rules          []string            `json:"rules"`
+		Encoders       map[string][]string `json:"encoders"`
+		Recursion      bool                `json:"recursion"`
+		RecursionDepth int                 `json:"recursionDepth"`
+	}{
+		URL:         f.URL,
+		Method:      f.Method,
+		Headers:     f.Headers,
+		Cookies:     f.Cookies,
+		BasicAuth:   f.BasicAuth,
+		BearerToken: f.BearerToken,
+		Body:        f.Body,
+		WordLists:   f.WordLists,
+		Mode:        f.Mode,
+		ScanType:    f.ScanType,
+
+		ParamsBatchSize: f.ParamsBatchSize,
+		ParamsLocation:  f.ParamsLocation,
+		Domain:          f.Domain,
+
+		Extensions:     f.Extensions,
+		ExtensionsOnly: f.ExtensionsOnly,
+		Rules:          f.rules,
+		Encoders:       f.Encoders,
+		Recursion:      f.Recursion,
+		RecursionDepth: f.RecursionDepth,
+	})
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCheckpoint loads checkpoint from state file, missing state file means
+// that fuzzing starts from the beginning
+func (f *Fuzzer) loadCheckpoint() (err error) {
+	raw, err := os.ReadFile(f.StateFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			f.Log.Info("state file doesn't exist, starting from the beginning",
+				zap.String("stateFile", f.StateFile),
+			)
+			err = nil
+		}
+		return
+	}
+
+	var c checkpoint
+	err = json.Unmarshal(raw, &c)
+	if err != nil {
+		err = fmt.Errorf("error in parsing state file %s: %w", f.StateFile, err)
+		return
+	}
+
+	if c.ConfigHash != f.configHash() {
+		err = fmt.Errorf("state file %s was saved with different config", f.StateFile)
+		return
+	}
+
+	f.resumed = &c
+	f.calibration = c.Calibration
+
+	if len(c.Scans) > 0 {
+		f.recursion = newRecursion(c.Scans)
+	}
+
+	// jobs processed after last save of state file are processed again
+	f.stats = c.Stats
+	f.stats.Processed = c.Position + len(c.Processed)
+	return
+}
+
+// saveCheckpoint saves current state into state file, it is called only from
+// stats go routine which owns stats
+func (f *Fuzzer) saveCheckpoint() {
+	if f.StateFile == "" || f.progress == nil {
+		return
+	}
+
+	position, processed := f.progress.get()
+
+	raw, _ := json.MarshalIndent(checkpoint{
+		ConfigHash:  f.configHash(),
+		Position:    position,
+		Processed:   processed,
+		Stats:       f.stats,
+		Calibration: f.calibration,
+		Scans:       f.recursion.get(),
+		Updated:     time.Now(),
+	}, "", "  ")
+
+	// write into temporary file first, so state file is never partially written
+	tmp := f.StateFile + ".tmp"
+	err := os.WriteFile(tmp, raw, 0644)
+	if err == nil {
+		err = os.Rename(tmp, f.StateFile)
+	}
+
+	if err != nil {
+		f.Log.Error("error in saving state file",
+			zap.String("stateFile", f.StateFile),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,154 @@
+package fuzzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dpanic/fuzzer/src/request"
+
+	"go.uber.org/zap"
+)
+
+// validateDNS prepares dns scan, names are rendered from Domain template,
+// keyword of first word list is prepended if template has no keyword
+func (f *Fuzzer) validateDNS() (err error) {
+	if f.URL != "" {
+		err = errors.New("target URL and request file can't be used in dns scan")
+		return
+	}
+
+	if f.Recursion {
+		err = errors.New("recursion can't be used in dns scan")
+		return
+	}
+
+	f.Domain = strings.Trim(strings.TrimSpace(f.Domain), ".")
+	if f.Domain == "" {
+		err = errors.New("domain must be defined in dns scan")
+		return
+	}
+
+	isTemplate := false
+	for _, w := range f.WordLists {
+		isTemplate = isTemplate || strings.Contains(f.Domain, w.Keyword)
+	}
+
+	if !isTemplate {
+		f.Domain = f.WordLists[0].Keyword + "." + f.Domain
+	}
+
+	// wildcard DNS is detected instead
+	f.AutoCalibrate = false
+	return
+}
+
+// lookup resolves name of job, response holds its addresses
+func (f *Fuzzer) lookup(ctx context.Context, j job) (r *response, err error) {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = request.DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	addresses, err := f.resolver.LookupHost(ctx, j.URL)
+	if err != nil {
+		return
+	}
+
+	r = &response{
+		Addresses: addresses,
+	}
+	return
+}
+
+// detectWildcard resolves random names under domain, names resolved only to
+// addresses of wildcard records are filtered out
+func (f *Fuzzer) detectWildcard(ctx context.Context, log *zap.Logger) (err error) {
+	f.wildcard = make(map[string]bool)
+
+	failed := 0
+	for i := 0; i < f.CalibrationRequests; i++ {
+		input := make(map[string]string, len(f.keywords))
+		for _, keyword := range f.keywords {
+			input[keyword] = randomToken()
+		}
+
+		j := f.newJob(f.rootScan(), input, variant{})
+		r, err := f.lookup(ctx, j)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			log.Warn("error in wildcard lookup",
+				zap.String("name", j.URL),
+				zap.Error(err),
+			)
+			failed++
+			continue
+		}
+
+		for _, address := range r.Addresses {
+			f.wildcard[address] = true
+		}
+	}
+
+	if failed == f.CalibrationRequests {
+		err = fmt.Errorf("all %d wildcard lookups failed", failed)
+		return
+	}
+
+	if len(f.wildcard) > 0 {
+		log.Info("wildcard DNS detected",
+			zap.Strings("addresses", f.wildcardAddresses()),
+		)
+	}
+
+	return
+}
+
+// wildcardAddresses returns sorted addresses of wildcard records
+func (f *Fuzzer) wildcardAddresses() []string {
+	addresses := make([]string, 0, len(f.wildcard))
+	for address := range f.wildcard {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// isWildcard checks if all addresses belong to wildcard records
+func (f *Fuzzer) isWildcard(addresses []string) bool {
+	for _, address := range addresses {
+		if !f.wildcard[address] {
+			return false
+		}
+	}
+
+	return len(f.wildcard) > 0
+}
+
+// saveHost saves name which exists and isn't resolved by wildcard record
+func (f *Fuzzer) saveHost(j job, r *response) {
+	if len(r.Addresses) == 0 || f.isWildcard(r.Addresses) {
+		return
+	}
+
+	f.statsQueue <- "saved"
+
+	f.results <- Result{
+		Host:      j.URL,
+		Input:     j.Input,
+		Payload:   j.Payload,
+		Rule:      j.Rule,
+		Extension: j.Extension,
+		Addresses: r.Addresses,
+	}
+}
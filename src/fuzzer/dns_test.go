@@ -0,0 +1,166 @@
+package fuzzer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpanic/fuzzer/src/request/dnstest"
+)
+
+func TestDNSScan(t *testing.T) {
+	s, err := dnstest.NewServer(map[string]string{
+		"www.fz.test":    "10.0.0.1",
+		"mail.fz.test":   "10.0.0.2",
+		"*.wild.test":    "10.9.9.9",
+		"www.wild.test":  "10.1.1.1",
+		"mail.wild.test": "10.9.9.9",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	dir := t.TempDir()
+	wordList := filepath.Join(dir, "words.txt")
+	err = os.WriteFile(wordList, []byte("www\nmail\nnope\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		domain   string
+		hosts    map[string][]string
+		wildcard []string
+	}{
+		{
+			domain: "fz.test",
+			hosts: map[string][]string{
+				"www.fz.test":  {"10.0.0.1"},
+				"mail.fz.test": {"10.0.0.2"},
+			},
+		},
+		{
+			// names resolved to wildcard address are filtered out
+			domain: "FUZZ.wild.test",
+			hosts: map[string][]string{
+				"www.wild.test": {"10.1.1.1"},
+			},
+			wildcard: []string{"10.9.9.9"},
+		},
+	}
+
+	for _, tt := range tests {
+		outFile := filepath.Join(dir, "out.json")
+
+		f, err := New(&Config{
+			ScanType:  ScanTypeDNS,
+			Domain:    tt.domain,
+			Resolvers: []string{s.Addr},
+			WordList:  wordList,
+			OutFile:   outFile,
+			Threads:   2,
+			Timeout:   time.Second,
+			IsSilent:  true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = f.Run(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.domain, err)
+		}
+
+		hosts, wildcard := readDNSResults(t, outFile)
+		if !reflect.DeepEqual(hosts, tt.hosts) {
+			t.Errorf("%s: hosts = %v, want %v", tt.domain, hosts, tt.hosts)
+		}
+
+		if !reflect.DeepEqual(wildcard, tt.wildcard) {
+			t.Errorf("%s: wildcard = %v, want %v", tt.domain, wildcard, tt.wildcard)
+		}
+	}
+}
+
+// readDNSResults reads hosts and wildcard addresses from out file
+func readDNSResults(t *testing.T, path string) (hosts map[string][]string, wildcard []string) {
+	t.Helper()
+
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	hosts = make(map[string][]string)
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, `{"wildcard"`) {
+			var h header
+			err = json.Unmarshal([]byte(line), &h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wildcard = h.Wildcard
+			continue
+		}
+
+		var r Result
+		err = json.Unmarshal([]byte(line), &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(r.Addresses)
+		hosts[r.Host] = r.Addresses
+	}
+
+	return
+}
+
+func TestValidateDNS(t *testing.T) {
+	tests := []struct {
+		config Config
+		domain string
+		isErr  bool
+	}{
+		{Config{Domain: "example.com."}, "FUZZ.example.com", false},
+		{Config{Domain: "dev-FUZZ.example.com"}, "dev-FUZZ.example.com", false},
+		{Config{Domain: ""}, "", true},
+		{Config{Domain: "example.com", URL: "http://example.com/FUZZ"}, "", true},
+		{Config{Domain: "example.com", Recursion: true}, "", true},
+	}
+
+	for _, tt := range tests {
+		f := &Fuzzer{tt.config}
+		f.WordLists = []WordList{{Keyword: "FUZZ"}}
+		f.AutoCalibrate = true
+
+		err := f.validateDNS()
+		if tt.isErr {
+			if err == nil {
+				t.Errorf("validateDNS(%+v) expected error", tt.config)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("validateDNS(%+v) error: %v", tt.config, err)
+			continue
+		}
+
+		if f.Domain != tt.domain || f.AutoCalibrate {
+			t.Errorf("validateDNS(%+v) domain = %s, autoCalibrate = %v", tt.config, f.Domain, f.AutoCalibrate)
+		}
+	}
+}
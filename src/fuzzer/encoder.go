@@ -0,0 +1,194 @@
+package fuzzer
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// encoder transforms payload before it is substituted into request
+type encoder func(payload string) string
+
+// encoderChain applies encoders one after another
+type encoderChain []encoder
+
+func (c encoderChain) encode(payload string) string {
+	for _, e := range c {
+		payload = e(payload)
+	}
+
+	return payload
+}
+
+// ParseEncoders parses encoder chain of keyword in format
+// KEYWORD:urlencode,prefix:../,base64
+func ParseEncoders(input string) (keyword string, chain []string, err error) {
+	idx := strings.Index(input, ":")
+	if idx <= 0 || idx == len(input)-1 {
+		err = fmt.Errorf("invalid encoders %s, expected KEYWORD:encoder,encoder", input)
+		return
+	}
+
+	keyword = input[:idx]
+	for _, spec := range strings.Split(input[idx+1:], ",") {
+		if spec == "" {
+			continue
+		}
+		chain = append(chain, spec)
+	}
+
+	return
+}
+
+// newEncoder creates encoder from spec in format name or name:argument
+func newEncoder(spec string) (e encoder, err error) {
+	name, argument, hasArgument := strings.Cut(spec, ":")
+
+	switch name {
+	case "urlencode":
+		e = urlEncode
+
+	case "double-urlencode":
+		e = func(payload string) string {
+			return urlEncode(urlEncode(payload))
+		}
+
+	case "base64":
+		e = func(payload string) string {
+			return base64.StdEncoding.EncodeToString([]byte(payload))
+		}
+
+	case "hex":
+		e = func(payload string) string {
+			return hex.EncodeToString([]byte(payload))
+		}
+
+	case "html-entity":
+		e = htmlEntityEncode
+
+	case "unicode-escape":
+		e = unicodeEscape
+
+	case "lowercase":
+		e = strings.ToLower
+
+	case "uppercase":
+		e = strings.ToUpper
+
+	case "prefix":
+		e = func(payload string) string {
+			return argument + payload
+		}
+
+	case "suffix":
+		e = func(payload string) string {
+			return payload + argument
+		}
+
+	default:
+		err = fmt.Errorf("unknown encoder %s", name)
+		return
+	}
+
+	isArgumentUsed := name == "prefix" || name == "suffix"
+	if isArgumentUsed != hasArgument {
+		e = nil
+		err = fmt.Errorf("invalid encoder %s, only prefix and suffix take argument", spec)
+	}
+
+	return
+}
+
+// urlEncode percent encodes all characters except unreserved ones
+func urlEncode(payload string) string {
+	var b strings.Builder
+	for i := 0; i < len(payload); i++ {
+		c := payload[i]
+		if isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	case c == '-', c == '.', c == '_', c == '~':
+		return true
+	}
+
+	return false
+}
+
+// htmlEntityEncode encodes every character as numeric HTML entity
+func htmlEntityEncode(payload string) string {
+	var b strings.Builder
+	for _, r := range payload {
+		fmt.Fprintf(&b, "&#%d;", r)
+	}
+
+	return b.String()
+}
+
+// unicodeEscape encodes every character as \uXXXX escape, characters outside
+// of basic plane are encoded as surrogate pairs
+func unicodeEscape(payload string) string {
+	var b strings.Builder
+	for _, u := range utf16.Encode([]rune(payload)) {
+		fmt.Fprintf(&b, "\\u%04x", u)
+	}
+
+	return b.String()
+}
+
+// validateEncoders compiles encoder chains of keywords
+func (f *Fuzzer) validateEncoders(keywords map[string]bool) (err error) {
+	f.encoders = make(map[string]encoderChain, len(f.Encoders))
+
+	for keyword, specs := range f.Encoders {
+		if !keywords[keyword] {
+			err = fmt.Errorf("encoders are defined for unknown keyword %s", keyword)
+			return
+		}
+
+		chain := make(encoderChain, 0, len(specs))
+		for _, spec := range specs {
+			var e encoder
+			e, err = newEncoder(spec)
+			if err != nil {
+				return
+			}
+			chain = append(chain, e)
+		}
+
+		if len(chain) > 0 {
+			f.encoders[keyword] = chain
+		}
+	}
+
+	return
+}
+
+// encode applies encoder chains to input, input is not modified
+func (f *Fuzzer) encode(input map[string]string) map[string]string {
+	if len(f.encoders) == 0 {
+		return input
+	}
+
+	res := make(map[string]string, len(input))
+	for keyword, value := range input {
+		if chain, ok := f.encoders[keyword]; ok {
+			value = chain.encode(value)
+		}
+		res[keyword] = value
+	}
+
+	return res
+}
@@ -4,4 +4,5 @@ import "errors"
 
 var (
 	ErrMaxRuntime = errors.New("command reached maximum runtime")
+	ErrStarted    = errors.New("fuzzer is already started")
 )
@@ -10,4 +10,13 @@ const (
 	EventTypeProgress   = "progress"
 	EventTypeThroughput = "throughput"
 	EventTypeError      = "error"
+
+	// EventTypeThrottled is sent when server responds with 429 or 503 and
+	// dispatch is paused, value is duration of pause
+	EventTypeThrottled = "throttled"
+
+	// EventTypePaused and EventTypeResumed are sent when dispatch of jobs is
+	// paused and resumed by Pause and Resume
+	EventTypePaused  = "paused"
+	EventTypeResumed = "resumed"
 )
@@ -0,0 +1,117 @@
+package fuzzer
+
+import (
+	"errors"
+	"strings"
+)
+
+// ParseExtensions parses comma separated extensions, .php,.bak,~
+func ParseExtensions(input string) (extensions []string) {
+	for _, e := range strings.Split(input, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		extensions = append(extensions, e)
+	}
+
+	return
+}
+
+// validateExtensions prepares suffixes appended to words of first word list,
+// empty suffix is bare word
+func (f *Fuzzer) validateExtensions() (err error) {
+	if f.ExtensionsOnly && len(f.Extensions) == 0 {
+		err = errors.New("extensions only is set, but extensions are not defined")
+		return
+	}
+
+	f.extensions = make([]string, 0, len(f.Extensions)+1)
+	if !f.ExtensionsOnly {
+		f.extensions = append(f.extensions, "")
+	}
+
+	for _, e := range f.Extensions {
+		if e == "" {
+			continue
+		}
+		f.extensions = append(f.extensions, e)
+	}
+
+	return
+}
+
+// variant is mutation of word of first word list, rule is applied first and
+// extension is appended to its result
+type variant struct {
+	rule      *rule
+	extension string
+}
+
+// validateVariants loads rules and combines them with extensions, every word
+// is requested in all variants
+func (f *Fuzzer) validateVariants() (err error) {
+	err = f.validateExtensions()
+	if err != nil {
+		return
+	}
+
+	// nil rule keeps word as it is
+	rules := []*rule{nil}
+	if f.RuleFile != "" {
+		rules, err = loadRules(f.RuleFile)
+		if err != nil {
+			return
+		}
+	}
+
+	f.rules = make([]string, 0, len(rules))
+	f.variants = make([]variant, 0, len(rules)*len(f.extensions))
+	for _, r := range rules {
+		if r != nil {
+			f.rules = append(f.rules, r.text)
+		}
+
+		for _, e := range f.extensions {
+			f.variants = append(f.variants, variant{
+				rule:      r,
+				extension: e,
+			})
+		}
+	}
+
+	return
+}
+
+// countJobs calculates total number of jobs from number of words in word lists
+func (f *Fuzzer) countJobs(counts []int) int {
+	total := countInputs(f.Mode, counts)
+	if total == unknownTotal {
+		return total
+	}
+
+	total *= len(f.variants)
+
+	// params scan sends words in batches
+	if f.ScanType == ScanTypeParams {
+		total = (total + f.ParamsBatchSize - 1) / f.ParamsBatchSize
+	}
+
+	return total
+}
+
+// extend applies variant to word of first word list, input is not modified
+func (f *Fuzzer) extend(input map[string]string, v variant) map[string]string {
+	if v.rule == nil && v.extension == "" {
+		return input
+	}
+
+	res := make(map[string]string, len(input))
+	for keyword, value := range input {
+		res[keyword] = value
+	}
+
+	keyword := f.WordLists[0].Keyword
+	res[keyword] = v.rule.apply(res[keyword]) + v.extension
+	return res
+}
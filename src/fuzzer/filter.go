@@ -1,37 +1,293 @@
 package fuzzer
 
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+const (
+	// CombineOr means that any of defined conditions has to be satisfied
+	CombineOr = "or"
+
+	// CombineAnd means that all of defined conditions have to be satisfied
+	CombineAnd = "and"
+)
+
+// Filters defines which results are excluded, every field is expression
+// parsed by ParseNumbers such as 403,500-599,>10000,5xx
 type Filters struct {
-	StatusCodes []int `json:"statusCodes"`
-	Words       []int `json:"words"`
-	Lines       []int `json:"lines"`
-	Size        []int `json:"size"`
+	StatusCodes string `json:"statusCodes"`
+	Words       string `json:"words"`
+	Lines       string `json:"lines"`
+	Size        string `json:"size"`
+
+	// Regex is matched against response body
+	Regex string `json:"regex"`
+
+	// Headers are regexes matched against values of response headers, by header name
+	Headers map[string]string `json:"headers"`
+}
+
+// Matchers defines which results are kept, if none is defined every result is kept
+type Matchers struct {
+	StatusCodes string `json:"statusCodes"`
+	Words       string `json:"words"`
+	Lines       string `json:"lines"`
+	Size        string `json:"size"`
+
+	// Regex is matched against response body
+	Regex string `json:"regex"`
+
+	// Headers are regexes matched against values of response headers, by header name
+	Headers map[string]string `json:"headers"`
+}
+
+// numberConditions are parsed numeric fields of Filters or Matchers
+type numberConditions struct {
+	StatusCodes numbers `json:"statusCodes"`
+	Words       numbers `json:"words"`
+	Lines       numbers `json:"lines"`
+	Size        numbers `json:"size"`
+}
+
+func parseNumberConditions(name, statusCodes, words, lines, size string) (c numberConditions, err error) {
+	fields := []struct {
+		name   string
+		input  string
+		output *numbers
+	}{
+		{"status codes", statusCodes, &c.StatusCodes},
+		{"words", words, &c.Words},
+		{"lines", lines, &c.Lines},
+		{"size", size, &c.Size},
+	}
+
+	for _, field := range fields {
+		*field.output, err = ParseNumbers(field.input)
+		if err != nil {
+			err = fmt.Errorf("error in parsing %s %s: %w", name, field.name, err)
+			return
+		}
+	}
+
+	return
+}
+
+// evaluate checks numeric conditions against response
+func (c *numberConditions) evaluate(r *response) (conditions []bool) {
+	conditions = make([]bool, 0, 4)
+
+	if len(c.StatusCodes) > 0 {
+		conditions = append(conditions, c.StatusCodes.contains(r.StatusCode))
+	}
+
+	if len(c.Lines) > 0 {
+		conditions = append(conditions, c.Lines.contains(r.Lines))
+	}
+
+	if len(c.Words) > 0 {
+		conditions = append(conditions, c.Words.contains(r.Words))
+	}
+
+	if len(c.Size) > 0 {
+		conditions = append(conditions, c.Size.contains(r.Size))
+	}
+
+	return
 }
 
-func (f *Fuzzer) filterResult(lines, words, size, statusCode int) (res bool) {
+// regexConditions are compiled regexes of Filters or Matchers
+type regexConditions struct {
+	body    *regexp.Regexp
+	headers map[string]*regexp.Regexp
+
+	// names are sorted names of headers, so matched patterns are reported in stable order
+	names []string
+}
 
-	for _, c := range f.Filters.StatusCodes {
-		if c == statusCode {
-			return false
+func compileRegexConditions(name, body string, headers map[string]string) (c regexConditions, err error) {
+	if body != "" {
+		c.body, err = regexp.Compile(body)
+		if err != nil {
+			err = fmt.Errorf("error in compiling %s regex: %w", name, err)
+			return
 		}
 	}
 
-	for _, l := range f.Filters.Lines {
-		if l == lines {
-			return false
+	c.headers = make(map[string]*regexp.Regexp, len(headers))
+	for header, expr := range headers {
+		c.headers[header], err = regexp.Compile(expr)
+		if err != nil {
+			err = fmt.Errorf("error in compiling %s regex for header %s: %w", name, header, err)
+			return
 		}
+		c.names = append(c.names, header)
 	}
+	sort.Strings(c.names)
+
+	return
+}
 
-	for _, w := range f.Filters.Words {
-		if w == words {
-			return false
+// evaluate checks regexes against response, matched contains description of
+// every pattern which matched
+func (c *regexConditions) evaluate(r *response) (conditions []bool, matched []string) {
+	conditions = make([]bool, 0, len(c.headers)+1)
+
+	if c.body != nil {
+		isMatched := c.body.Match(r.Body)
+		if isMatched {
+			matched = append(matched, "body: "+c.body.String())
 		}
+		conditions = append(conditions, isMatched)
 	}
 
-	for _, s := range f.Filters.Size {
-		if s == size {
-			return false
+	for _, header := range c.names {
+		expr := c.headers[header]
+		isMatched := false
+		for _, value := range r.Headers.Values(header) {
+			if expr.MatchString(value) {
+				isMatched = true
+				break
+			}
+		}
+
+		if isMatched {
+			matched = append(matched, fmt.Sprintf("header %s: %s", header, expr.String()))
 		}
+		conditions = append(conditions, isMatched)
+	}
+
+	return
+}
+
+// response is summary of response used for filtering and matching
+type response struct {
+	StatusCode       int
+	Lines            int
+	Words            int
+	Size             int
+	Body             []byte
+	Headers          http.Header
+	RedirectLocation string
+
+	// Addresses are addresses of name resolved in dns scan
+	Addresses []string
+}
+
+func newResponse(body []byte, statusCode int, headers http.Header) *response {
+	return &response{
+		StatusCode: statusCode,
+		Lines:      bytes.Count(body, []byte("\n")),
+		Words:      bytes.Count(body, []byte(" ")),
+		Size:       len(body),
+		Body:       body,
+		Headers:    headers,
+	}
+}
+
+func validateCombine(mode *string, name string) (err error) {
+	switch *mode {
+	case "":
+		*mode = CombineOr
+	case CombineOr, CombineAnd:
+	default:
+		err = fmt.Errorf("unknown %s %s, use or, and", name, *mode)
+	}
+
+	return
+}
+
+// combine combines results of defined conditions, defined is false when there are no conditions
+func combine(mode string, conditions []bool) (res, defined bool) {
+	if len(conditions) == 0 {
+		return
+	}
+
+	defined = true
+	res = mode == CombineAnd
+	for _, c := range conditions {
+		if mode == CombineAnd && !c {
+			return false, true
+		}
+
+		if mode == CombineOr && c {
+			return true, true
+		}
+	}
+
+	return
+}
+
+func (f *Fuzzer) isFiltered(r *response) bool {
+	if f.isCalibrated(r) {
+		return true
+	}
+
+	conditions := f.filters.evaluate(r)
+
+	regexes, _ := f.filterRegexes.evaluate(r)
+	conditions = append(conditions, regexes...)
+
+	res, _ := combine(f.FilterMode, conditions)
+	return res
+}
+
+func (f *Fuzzer) isMatched(r *response) (res bool, matched []string) {
+	conditions := f.matchers.evaluate(r)
+
+	regexes, matched := f.matcherRegexes.evaluate(r)
+	conditions = append(conditions, regexes...)
+
+	res, defined := combine(f.MatcherMode, conditions)
+	if !defined {
+		return true, nil
+	}
+
+	return
+}
+
+// filterResult returns true if result should be saved, matched describes
+// matcher patterns which matched
+func (f *Fuzzer) filterResult(r *response) (res bool, matched []string) {
+	res, matched = f.isMatched(r)
+	if !res {
+		return
+	}
+
+	res = !f.isFiltered(r)
+	return
+}
+
+// compileConditions parses filters and matchers
+func (f *Fuzzer) compileConditions() (err error) {
+	f.filters, err = parseNumberConditions("filter",
+		f.Filters.StatusCodes,
+		f.Filters.Words,
+		f.Filters.Lines,
+		f.Filters.Size,
+	)
+	if err != nil {
+		return
+	}
+
+	f.matchers, err = parseNumberConditions("matcher",
+		f.Matchers.StatusCodes,
+		f.Matchers.Words,
+		f.Matchers.Lines,
+		f.Matchers.Size,
+	)
+	if err != nil {
+		return
+	}
+
+	f.filterRegexes, err = compileRegexConditions("filter", f.Filters.Regex, f.Filters.Headers)
+	if err != nil {
+		return
 	}
 
-	return true
+	f.matcherRegexes, err = compileRegexConditions("matcher", f.Matchers.Regex, f.Matchers.Headers)
+	return
 }
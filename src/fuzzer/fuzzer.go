@@ -1,9 +1,10 @@
 package fuzzer
 
 import (
-	"bufio"
+	"context"
+	"crypto/tls"
 	"errors"
-	"io"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
@@ -30,12 +31,102 @@ type Config struct {
 	// Method defines which HTTP method should be used
 	Method string `json:"method"`
 
-	// WordList defines which word list should be used in fuzzing
+	// ScanType defines what is fuzzed, http, vhost, params or dns, http by default
+	ScanType string `json:"scanType"`
+
+	// VHost defines template of Host header in vhost scan, FUZZ.example.com,
+	// keyword of first word list by default
+	VHost string `json:"vhost"`
+
+	// ParamsBatchSize defines number of parameters sent in one request in
+	// params scan
+	ParamsBatchSize int `json:"paramsBatchSize"`
+
+	// ParamsLocation defines where parameters are sent in params scan, query
+	// or body
+	ParamsLocation string `json:"paramsLocation"`
+
+	// Domain defines template of names in dns scan, FUZZ.example.com, keyword
+	// of first word list is prepended if template has no keyword
+	Domain string `json:"domain"`
+
+	// Resolvers defines DNS servers used round-robin in dns scan and for hosts
+	// of HTTP requests, ip or ip:port, system resolver is used if not set
+	Resolvers []string `json:"resolvers"`
+
+	// ResolverProtocol defines protocol of Resolvers, udp or tcp, udp by default
+	ResolverProtocol string `json:"resolverProtocol"`
+
+	// RequestFile defines raw HTTP request used as template instead of URL,
+	// it defines method, URL, headers and body, Headers are added to its headers
+	RequestFile string `json:"requestFile"`
+
+	// RequestScheme defines scheme of request file, https by default
+	RequestScheme string `json:"requestScheme"`
+
+	// RequestHost overrides Host header of request file
+	RequestHost string `json:"requestHost"`
+
+	// Headers defines custom headers sent with every request, keywords are
+	// replaced both in names and values
+	Headers http.Header `json:"headers"`
+
+	// Cookies defines cookies sent with every request in format name=value
+	Cookies []string `json:"cookies"`
+
+	// BasicAuth defines basic auth credentials in format user:password
+	BasicAuth string `json:"basicAuth"`
+
+	// BearerToken defines token sent in Authorization header
+	BearerToken string `json:"bearerToken"`
+
+	// Body defines request body
+	Body string `json:"body"`
+
+	// WordList defines which word list should be used in fuzzing, it is bound
+	// to FUZZ keyword, - reads stdin, .gz and .zst lists are decompressed
 	WordList string `json:"wordList"`
 
+	// WordLists defines word lists bound to named keywords
+	WordLists []WordList `json:"wordLists"`
+
+	// Mode defines how multiple word lists are combined, clusterbomb or pitchfork
+	Mode string `json:"mode"`
+
+	// Extensions are appended to every word of first word list, every word is
+	// requested bare and with each extension
+	Extensions []string `json:"extensions"`
+
+	// ExtensionsOnly skips requesting bare words when extensions are defined
+	ExtensionsOnly bool `json:"extensionsOnly"`
+
+	// RuleFile defines file with hashcat style rules, every word of first word
+	// list is requested mutated by each rule
+	RuleFile string `json:"ruleFile"`
+
+	// Encoders defines chain of encoders applied to words of keyword before
+	// substitution, urlencode, double-urlencode, base64, hex, html-entity,
+	// unicode-escape, lowercase, uppercase, prefix:value and suffix:value
+	Encoders map[string][]string `json:"encoders"`
+
+	// Recursion scans every found directory with the same word lists, URL
+	// must end with keyword of first word list
+	Recursion bool `json:"recursion"`
+
+	// RecursionDepth defines maximum depth of recursion
+	RecursionDepth int `json:"recursionDepth"`
+
 	// OutFile defines output of fuzzing process
 	OutFile string `json:"outFile"`
 
+	// StateFile defines where state of fuzzer is periodically saved, so it can
+	// be resumed if interrupted
+	StateFile string `json:"stateFile"`
+
+	// ResumeFromState resumes fuzzing from StateFile, already processed jobs
+	// are skipped and results and failed words are appended to OutFile and FailedFile
+	ResumeFromState bool `json:"resumeFromState"`
+
 	// MaxTime defines maximum runtime of fuzzer, if not set then indefinite
 	MaxTime time.Duration `json:"maxTime"`
 
@@ -43,9 +134,42 @@ type Config struct {
 	// if not set than no limits are applied
 	MaxReqSec int `json:"maxReqSec"`
 
+	// Retries defines how many times job is retried on timeouts, connection
+	// resets and 502, 504 responses, with exponential backoff between retries
+	Retries int `json:"retries"`
+
+	// FailedFile defines where words of permanently failed jobs are written,
+	// with multiple word lists file per keyword is written as FailedFile.KEYWORD
+	FailedFile string `json:"failedFile"`
+
+	// IgnoreRateLimit disables pausing when server responds with 429 or 503
+	// with Retry-After, such responses are then saved as regular results
+	IgnoreRateLimit bool `json:"ignoreRateLimit"`
+
 	// Filters perform filtering out of results per words, lines, size of body etc
 	Filters Filters `json:"filters"`
 
+	// FilterMode defines how filters are combined, or (default), and
+	FilterMode string `json:"filterMode"`
+
+	// Matchers keep only results which match per status code, words, lines,
+	// size of body or regex, if not set all results are kept
+	Matchers Matchers `json:"matchers"`
+
+	// MatcherMode defines how matchers are combined, or (default), and
+	MatcherMode string `json:"matcherMode"`
+
+	// AutoCalibrate requests random non existent resources before fuzzing and
+	// filters out responses which look the same (soft 404, wildcard responses)
+	AutoCalibrate bool `json:"autoCalibrate"`
+
+	// CalibrationRequests defines number of calibration requests
+	CalibrationRequests int `json:"calibrationRequests"`
+
+	// CalibrationTolerance defines how much size of body can differ from
+	// calibrated responses to be filtered out
+	CalibrationTolerance int `json:"calibrationTolerance"`
+
 	// UserAgent defines custom user agent
 	UserAgent string `json:"userAgent"`
 
@@ -55,6 +179,26 @@ type Config struct {
 	// ProxyURL defines HTTP forwarding proxy if set
 	ProxyURL string `json:"proxyURL"`
 
+	// Threads defines number of workers, if not set it is 4 per CPU, minimum 32
+	Threads int `json:"threads"`
+
+	// Timeout defines maximum duration of single request
+	Timeout time.Duration `json:"timeout"`
+
+	// DialTimeout defines maximum duration of establishing TCP connection
+	DialTimeout time.Duration `json:"dialTimeout"`
+
+	// TLSHandshakeTimeout defines maximum duration of TLS handshake
+	TLSHandshakeTimeout time.Duration `json:"tlsHandshakeTimeout"`
+
+	// MaxReadSize defines maximum number of bytes read from response body,
+	// larger bodies are truncated and length of result is truncated length
+	MaxReadSize int `json:"maxReadSize"`
+
+	// TLSConfig defines TLS settings of HTTP client, by default certificates
+	// are not verified
+	TLSConfig *tls.Config `json:"-"`
+
 	// IsSilent defines should fuzzer perform detailed logging or not
 	IsSilent bool `json:"isSilent"`
 
@@ -63,12 +207,63 @@ type Config struct {
 	// err is external error which will be returned by .Wait() method
 	err error
 
-	// Done channel is used for close initialized by command line
-	Done chan string `json:"exit"`
+	// cancel cancels context of started fuzzer
+	cancel context.CancelFunc
+
+	// isStarted is set when fuzzer is started by Start
+	isStarted bool
+
+	// isStopped is set when fuzzer is stopped by Stop
+	isStopped bool
+
+	// done is closed when started fuzzer is finished
+	done chan struct{}
+
+	// isFinished is set when fuzzer is finished and events are closed
+	isFinished bool
+
+	// isPaused is set when dispatch of jobs is paused, unpaused is closed on resume
+	isPaused bool
+	unpaused chan struct{}
 
 	// Events sends events by fuzzer, which can be parsed by third party
 	Events chan Event `json:"events"`
 
+	// filters and matchers are parsed Filters and Matchers
+	filters  numberConditions
+	matchers numberConditions
+
+	// filterRegexes and matcherRegexes are compiled regexes of Filters and Matchers
+	filterRegexes  regexConditions
+	matcherRegexes regexConditions
+
+	// calibration holds fingerprints learned in auto calibration
+	calibration []fingerprint
+
+	// keywords are keywords of all word lists, sorted for substitution
+	keywords []string
+
+	// extensions are suffixes requested for every word, empty one is bare word
+	extensions []string
+
+	// rules are lines of rule file
+	rules []string
+
+	// variants are combinations of rules and extensions requested for every word
+	variants []variant
+
+	// encoders are compiled encoder chains of keywords
+	encoders map[string]encoderChain
+
+	// paramValue is value of parameters in params scan
+	paramValue string
+
+	// wildcard holds addresses of wildcard DNS records detected in dns scan
+	wildcard map[string]bool
+
+	// recursion schedules scans of URL and found directories
+	recursion *recursion
+
 	// maxWorkers is used to determine maximum number of go routines
 	maxWorkers int
 	mutex      *sync.Mutex
@@ -80,13 +275,28 @@ type Config struct {
 	// jobs is channel for streaming new jobs red from wordlist
 	jobs chan job
 
-	// control channel is used for shutting down started go routines such as
-	// workers, fan in, stats, results
-	control chan bool
+	// requester sends requests with HTTP client owned by this fuzzer
+	requester *request.Requester
 
-	// burstyLimiter is channel used for creating ticks and by it controlling limit rate
-	// of fuzzer
-	burstyLimiter chan bool
+	// resolver resolves names in dns scan and hosts of HTTP requests
+	resolver *request.Resolver
+
+	// throttle pauses dispatch of jobs when server is throttling requests
+	throttle *throttle
+
+	// failed writes words of permanently failed jobs into FailedFile
+	failed *failedWriter
+
+	// progress tracks position until which all jobs are processed
+	progress *progress
+
+	// resumed is checkpoint from which fuzzer is resumed
+	resumed *checkpoint
+
+	// total is total number of jobs, counted in background, it is synced
+	// into stats by stats go routine
+	total            int
+	isTotalEstimated bool
 
 	// stats defines stats, total, processed, errors etc.
 	stats stats
@@ -94,9 +304,6 @@ type Config struct {
 	// statsQueue is used for sending results to stats structure
 	statsQueue chan string
 
-	// totalWorkers is used to determine how many go routines are up and running
-	totalWorkers int
-
 	// Started defines at which time fuzzer is started
 	Started time.Time
 
@@ -106,9 +313,9 @@ type Config struct {
 
 // Validate validates input params
 func (f *Fuzzer) validate() (err error) {
-	// set max worktime
-	if f.MaxTime < 10 {
-		f.MaxTime = 3600
+	if f.MaxTime < 0 {
+		err = errors.New("max time can't be negative")
+		return
 	}
 
 	// set where to save output
@@ -117,11 +324,83 @@ func (f *Fuzzer) validate() (err error) {
 		os.MkdirAll("tmp", 0755)
 	}
 
-	if f.WordList == "" {
+	// request file defines URL, method, headers and body, so it is loaded
+	// before they are checked
+	err = f.loadRequestFile()
+	if err != nil {
+		return
+	}
+
+	if f.WordList != "" {
+		f.WordLists = append([]WordList{
+			{
+				Path:    f.WordList,
+				Keyword: DefaultKeyword,
+			},
+		}, f.WordLists...)
+	}
+
+	if len(f.WordLists) == 0 {
 		err = errors.New("word list must be defined")
 		return
 	}
 
+	keywords := make(map[string]bool, len(f.WordLists))
+	isStdinUsed := false
+	for _, w := range f.WordLists {
+		if w.Path == "" || w.Keyword == "" {
+			err = errors.New("word list path and keyword must be defined")
+			return
+		}
+
+		// generators are checked early, so invalid definition is reported by New
+		if isGenerator(w.Path) {
+			_, err = newGenerator(w.Path)
+			if err != nil {
+				return
+			}
+		}
+
+		if w.Path == StdinPath {
+			if isStdinUsed {
+				err = errors.New("stdin can be used only by one word list")
+				return
+			}
+			isStdinUsed = true
+		}
+
+		if keywords[w.Keyword] {
+			err = fmt.Errorf("keyword %s is defined more than once", w.Keyword)
+			return
+		}
+		keywords[w.Keyword] = true
+	}
+
+	err = f.validateEncoders(keywords)
+	if err != nil {
+		return
+	}
+
+	// set mode
+	switch f.Mode {
+	case "":
+		f.Mode = ModeClusterbomb
+	case ModeClusterbomb, ModePitchfork:
+	default:
+		err = fmt.Errorf("unknown mode %s", f.Mode)
+		return
+	}
+
+	err = f.validateVariants()
+	if err != nil {
+		return
+	}
+
+	err = f.validateRecursion()
+	if err != nil {
+		return
+	}
+
 	// set proxy url
 	_, err = url.Parse(f.ProxyURL)
 	if err != nil {
@@ -129,8 +408,67 @@ func (f *Fuzzer) validate() (err error) {
 	}
 	err = nil
 
-	// set target url
-	if f.URL == "" {
+	err = validateCombine(&f.FilterMode, "filter mode")
+	if err != nil {
+		return
+	}
+
+	err = validateCombine(&f.MatcherMode, "matcher mode")
+	if err != nil {
+		return
+	}
+
+	err = f.compileConditions()
+	if err != nil {
+		return
+	}
+
+	if f.ResumeFromState && f.StateFile == "" {
+		err = errors.New("state file must be defined to resume")
+		return
+	}
+
+	if f.Threads < 0 {
+		err = errors.New("threads can't be negative")
+		return
+	}
+
+	if f.Timeout < 0 || f.DialTimeout < 0 || f.TLSHandshakeTimeout < 0 {
+		err = errors.New("timeouts can't be negative")
+		return
+	}
+
+	if f.MaxReadSize < 0 {
+		err = errors.New("max read size can't be negative")
+		return
+	}
+
+	if f.Retries < 0 {
+		err = errors.New("retries can't be negative")
+		return
+	}
+
+	if f.CalibrationRequests <= 0 {
+		f.CalibrationRequests = defaultCalibrationRequests
+	}
+
+	if f.CalibrationTolerance < 0 {
+		err = errors.New("calibration tolerance can't be negative")
+		return
+	}
+
+	if f.BasicAuth != "" && f.BearerToken != "" {
+		err = errors.New("basic auth and bearer token can't be used together")
+		return
+	}
+
+	err = f.validateScanType()
+	if err != nil {
+		return
+	}
+
+	// set target url, dns scan has no URL
+	if f.URL == "" && f.ScanType != ScanTypeDNS {
 		err = errors.New("target URL must be defined")
 		return
 	}
@@ -141,9 +479,78 @@ func (f *Fuzzer) validate() (err error) {
 		return
 	}
 
+	// params scan adds parameters itself
+	for _, w := range f.WordLists {
+		if f.ScanType != ScanTypeParams && !f.isKeywordUsed(w.Keyword) {
+			err = fmt.Errorf("keyword %s is not used in url, method, headers or body", w.Keyword)
+			return
+		}
+	}
+
 	return
 }
 
+// isKeywordUsed checks if keyword is used in any of fuzzing positions
+func (f *Fuzzer) isKeywordUsed(keyword string) bool {
+	// dns scan fuzzes only names
+	if f.ScanType == ScanTypeDNS {
+		return strings.Contains(f.Domain, keyword)
+	}
+
+	if strings.Contains(f.URL, keyword) ||
+		strings.Contains(f.Method, keyword) ||
+		strings.Contains(f.Body, keyword) ||
+		strings.Contains(f.BasicAuth, keyword) ||
+		strings.Contains(f.BearerToken, keyword) {
+		return true
+	}
+
+	for _, cookie := range f.Cookies {
+		if strings.Contains(cookie, keyword) {
+			return true
+		}
+	}
+
+	for name, values := range f.Headers {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+
+		for _, value := range values {
+			if strings.Contains(value, keyword) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasHeader checks if header is defined, names of Headers aren't canonicalized
+// since they can contain keywords
+func (f *Fuzzer) hasHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	for n := range f.Headers {
+		if http.CanonicalHeaderKey(n) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setHeader sets header on copy of Headers, headers of config are not
+// modified, since they can be shared with caller
+func (f *Fuzzer) setHeader(name, value string) {
+	headers := f.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	headers.Set(name, value)
+	f.Headers = headers
+}
+
 // New generates basic new instance of Fuzzer
 func New(config *Config) (f *Fuzzer, err error) {
 	f = &Fuzzer{
@@ -155,266 +562,555 @@ func New(config *Config) (f *Fuzzer, err error) {
 		return
 	}
 
-	f.maxWorkers = runtime.NumCPU() * 4
-	if f.maxWorkers < 32 {
-		f.maxWorkers = 32
+	f.maxWorkers = f.Threads
+	if f.maxWorkers == 0 {
+		f.maxWorkers = runtime.NumCPU() * 4
+		if f.maxWorkers < 32 {
+			f.maxWorkers = 32
+		}
 	}
 
 	f.jobs = make(chan job, f.maxWorkers*4)
 	f.results = make(chan Result, f.maxWorkers*4)
-	f.control = make(chan bool, f.maxWorkers+3)
 	f.mutex = &sync.Mutex{}
 	f.statsQueue = make(chan string, f.maxWorkers*4)
-	f.burstyLimiter = make(chan bool, 1)
-	f.Done = make(chan string, 1)
+	f.done = make(chan struct{})
 	f.Events = make(chan Event, f.maxWorkers*4)
 	f.Started = time.Now()
+	f.throttle = newThrottle()
+	f.keywords = sortKeywords(f.WordLists)
+	f.recursion = newRecursion([]scan{f.rootScan()})
 
 	if f.Log == nil {
 		f.Log = logger.Log
 	}
 
-	request.Setup(f.ProxyURL)
+	f.resolver, err = request.NewResolver(f.Resolvers, f.ResolverProtocol, f.DialTimeout)
+	if err != nil {
+		return
+	}
 
-	f.totalWorkers = f.maxWorkers
-	f.totalWorkers += 3 // fanin + results worker
+	f.requester = request.New(request.Options{
+		ProxyURL:            f.ProxyURL,
+		Timeout:             f.Timeout,
+		DialTimeout:         f.DialTimeout,
+		TLSHandshakeTimeout: f.TLSHandshakeTimeout,
+		MaxReadSize:         f.MaxReadSize,
+		TLSConfig:           f.TLSConfig,
+		Resolver:            f.resolver,
+		Log:                 f.Log,
+	})
+
+	if f.ResumeFromState {
+		err = f.loadCheckpoint()
+		if err != nil {
+			return
+		}
+	}
+
+	if f.FailedFile != "" {
+		f.failed, err = openFailedWriter(f.FailedFile, f.WordLists, f.resumed != nil)
+		if err != nil {
+			return
+		}
+	}
 
 	return
 }
 
 type job struct {
-	URL string `json:"url"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers http.Header       `json:"headers"`
+	Body    []byte            `json:"body"`
+	Input   map[string]string `json:"input"`
+
+	// Rule is applied to word of first word list
+	Rule string `json:"rule"`
+
+	// Extension is appended to word of first word list
+	Extension string `json:"extension"`
+
+	// Payload holds encoded words, it is set only if encoders are defined
+	Payload map[string]string `json:"payload"`
+
+	// index is sequence number of job
+	index int
+
+	// depth is depth of recursion of scan which produced job
+	depth int
+
+	// params are names of parameters sent in params scan
+	params []string
+}
+
+// newJob renders request template of scan by replacing keywords with input
+// values, variant is applied to word of first word list
+func (f *Fuzzer) newJob(s scan, input map[string]string, v variant) (j job) {
+	j = job{
+		Headers:   make(http.Header, len(f.Headers)),
+		Input:     input,
+		Extension: v.extension,
+		depth:     s.Depth,
+	}
+
+	if v.rule != nil {
+		j.Rule = v.rule.text
+	}
+
+	// input is kept raw, so failed words can be re-run with the same rules,
+	// extensions and encoders
+	input = f.encode(f.extend(input, v))
+	if len(f.encoders) > 0 {
+		j.Payload = input
+	}
+
+	replacer := f.replacer(input)
+	j.URL = replacer.Replace(s.URL)
+	j.Method = replacer.Replace(f.Method)
+
+	if f.Body != "" {
+		j.Body = []byte(replacer.Replace(f.Body))
+	}
+
+	for name, values := range f.Headers {
+		name = replacer.Replace(name)
+		for _, value := range values {
+			j.Headers.Add(name, replacer.Replace(value))
+		}
+	}
+
+	if len(f.Cookies) > 0 {
+		cookies := make([]string, 0, len(f.Cookies))
+		for _, cookie := range f.Cookies {
+			cookies = append(cookies, replacer.Replace(cookie))
+		}
+		j.Headers.Add("Cookie", strings.Join(cookies, "; "))
+	}
+
+	switch {
+	case f.BasicAuth != "":
+		j.Headers.Set("Authorization", request.BasicAuth(replacer.Replace(f.BasicAuth)))
+	case f.BearerToken != "":
+		j.Headers.Set("Authorization", request.BearerAuth(replacer.Replace(f.BearerToken)))
+	}
+
+	return
+}
+
+// Run starts fuzzer and blocks until all jobs are processed, maximum runtime
+// is reached or ctx is canceled. ErrMaxRuntime is returned when maximum runtime
+// is reached and ctx.Err() when ctx is canceled
+func (f *Fuzzer) Run(ctx context.Context) (err error) {
+	err = f.Start(ctx)
+	if err != nil {
+		return
+	}
+
+	return f.Wait()
+}
+
+// Start starts fuzzer in background, Wait returns when it is finished. Fuzzer
+// can be started only once, ErrStarted is returned otherwise
+func (f *Fuzzer) Start(ctx context.Context) (err error) {
+	f.mutex.Lock()
+	if f.isStarted {
+		f.mutex.Unlock()
+		err = ErrStarted
+		return
+	}
+
+	f.isStarted = true
+	ctx, f.cancel = context.WithCancel(ctx)
+	f.Started = time.Now()
+	f.mutex.Unlock()
+
+	go func() {
+		err := f.run(ctx)
+		f.setError(err)
+		close(f.done)
+	}()
+
+	return
+}
+
+// Wait waits until started fuzzer is finished and returns its error, it
+// returns immediately if fuzzer is not started
+func (f *Fuzzer) Wait() (err error) {
+	f.mutex.Lock()
+	isStarted := f.isStarted
+	f.mutex.Unlock()
+
+	if !isStarted {
+		return
+	}
+
+	<-f.done
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.err
+}
+
+func (f *Fuzzer) setError(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.err = err
 }
 
-func (f *Fuzzer) Start() {
+// Stop cancels started fuzzer and waits until all go routines are shut down
+func (f *Fuzzer) Stop() {
+	f.mutex.Lock()
+	cancel := f.cancel
+	f.isStopped = cancel != nil
+	f.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	f.Wait()
+}
+
+func (f *Fuzzer) run(ctx context.Context) (err error) {
 	log := f.Log.WithOptions(zap.Fields(
 		zap.String("url", f.URL),
 		zap.String("method", f.Method),
-		zap.String("wordList", f.WordList),
+		zap.Any("wordLists", f.WordLists),
+		zap.String("mode", f.Mode),
 		zap.String("outFile", f.OutFile),
 		zap.Any("filters", f.Filters),
+		zap.Any("matchers", f.Matchers),
 		zap.Duration("maxTime", f.MaxTime),
 	))
 
-	// check main url
-	main := strings.ReplaceAll(f.URL, "FUZZ", "")
-	_, statusCode, _, err := request.Do(main, f.Method, nil, nil, f.Log)
+	defer func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
 
-	if err != nil && statusCode != 0 {
-		err = errors.New("error in connecting to main url of server")
-		log.Warn(err.Error())
-		f.Done <- err.Error()
-		f.setError(err)
+		f.isFinished = true
+		close(f.Events)
+	}()
 
-		// not proud of this, but for now leave it
-		f.totalWorkers = 0
-		return
+	if f.failed != nil {
+		defer f.failed.close()
 	}
 
-	// max runtime go routine
-	if f.MaxTime.Seconds() > 1 {
+	// max runtime
+	runCtx := ctx
+	if f.MaxTime > 0 {
 		log.Warn("max time is defined. setting countdown")
-		go func() {
-			<-time.After(f.MaxTime)
-			log.Warn("fuzzer timeouted",
-				zap.Duration("duraiton", time.Since(f.Started)),
-			)
-			f.Stop()
-			f.Done <- "timeouted"
-			f.setError(ErrMaxRuntime)
-		}()
+
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, f.MaxTime)
+		defer cancel()
 	}
 
-	// wait until is done
-	isCounted := false
-	go func() {
-		for {
-			time.Sleep(3 * time.Second)
-
-			if isCounted && f.stats.Total == f.stats.Processed {
-				log.Info("fuzzer processed all",
-					zap.Int("total", f.stats.Total),
-					zap.Int("errors", f.stats.Errors),
-					zap.Int("processed", f.stats.Processed),
-				)
-
-				f.Stop()
-				f.Done <- "done"
-				return
-			}
+	defer func() {
+		if err != nil {
+			return
 		}
-	}()
 
-	// shutdown fan in
-	defer func() {
 		f.mutex.Lock()
 		defer f.mutex.Unlock()
 
-		if !f.IsSilent {
-			log.Debug("shutting down fan in",
-				zap.Int("totalWorkers", f.totalWorkers),
+		switch {
+		case ctx.Err() != nil:
+			if !f.isStopped {
+				err = ctx.Err()
+			}
+
+		case runCtx.Err() != nil:
+			log.Warn("fuzzer timeouted",
+				zap.Duration("duration", time.Since(f.Started)),
 			)
+			err = ErrMaxRuntime
 		}
-		f.totalWorkers--
 	}()
 
-	// start workers
-	for i := 0; i < f.maxWorkers; i++ {
-		go f.Worker(i)
+	// check main url, dns scan sends no HTTP requests
+	if f.ScanType != ScanTypeDNS {
+		main := f.newJob(f.rootScan(), nil, variant{})
+
+		var statusCode int
+		_, statusCode, _, _, err = f.requester.Do(runCtx, main.URL, main.Method, main.Body, main.Headers)
+
+		if err != nil && statusCode != 0 {
+			err = errors.New("error in connecting to main url of server")
+			log.Warn(err.Error())
+			return
+		}
+		err = nil
+	}
+
+	if f.ScanType == ScanTypeDNS {
+		err = f.detectWildcard(runCtx, log)
+		if err != nil {
+			if runCtx.Err() != nil {
+				err = nil
+				return
+			}
+
+			err = fmt.Errorf("error in wildcard detection: %w", err)
+			log.Warn(err.Error())
+			return
+		}
+	}
+
+	// calibration is restored when fuzzer is resumed
+	if f.AutoCalibrate && len(f.calibration) == 0 {
+		err = f.calibrate(runCtx, log)
+		if err != nil {
+			if runCtx.Err() != nil {
+				err = nil
+				return
+			}
+
+			err = fmt.Errorf("error in calibration: %w", err)
+			log.Warn(err.Error())
+			return
+		}
 	}
 
-	// open wordlist
-	fd, err := os.OpenFile(f.WordList, os.O_RDONLY, os.ModePerm)
+	// open word lists
+	lists, err := openSources(f.Mode, f.WordLists, f.Recursion)
 	if err != nil {
 		log.Error("error in opening word list file",
 			zap.Error(err),
 		)
-		f.setError(err)
 		return
 	}
-	defer fd.Close()
-
-	rd := bufio.NewReader(fd)
+	defer func() {
+		for _, l := range lists {
+			l.close()
+		}
+	}()
 
-	// count lines
-	for {
-		_, err := rd.ReadString('\n')
+	f.estimateTotal(lists)
+
+	position := 0
+	var processed []int
+	if f.resumed != nil {
+		position = f.resumed.Position
+		processed = f.resumed.Processed
+		log.Info("resuming fuzzer",
+			zap.String("stateFile", f.StateFile),
+			zap.Int("position", position),
+			zap.Int("processed", len(processed)),
+		)
+	}
+	f.progress = newProgress(position, processed)
 
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			break
-		}
+	// open results, append to them if fuzzer is resumed
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if f.resumed != nil {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
 
-		f.stats.Total++
+	fd, err := os.OpenFile(f.OutFile, flags, 0644)
+	if err != nil {
+		log.Error("error in opening out file",
+			zap.Error(err),
+		)
+		return
 	}
-	fd.Seek(0, io.SeekStart)
-	isCounted = true
 
-	var (
-		shouldWork = true
-	)
+	// print stats
+	statsDone := make(chan struct{})
+	go func() {
+		f.processStats(3 * time.Second)
+		close(statsDone)
+	}()
 
-	// monitoring for control exit
+	// start results
+	resultsDone := make(chan struct{})
 	go func() {
-		<-f.control
+		f.saveResults(fd)
+		close(resultsDone)
+	}()
 
-		f.mutex.Lock()
-		defer f.mutex.Unlock()
+	// start workers
+	workers := &sync.WaitGroup{}
+	for i := 0; i < f.maxWorkers; i++ {
+		workers.Add(1)
+		go func(id int) {
+			defer workers.Done()
+			f.Worker(runCtx, id)
+		}(i)
+	}
 
-		shouldWork = false
+	err = f.produce(runCtx, lists, position, processed)
+	if err != nil {
+		log.Error("error in reading line from file",
+			zap.Error(err),
+		)
+	}
 
-		if len(f.burstyLimiter) == 0 {
-			f.burstyLimiter <- true
-		}
+	// shut down in order, workers are producing results and stats
+	close(f.jobs)
+	workers.Wait()
 
-		if len(f.jobs) > 0 {
-			<-f.jobs
-		}
-	}()
+	close(f.results)
+	<-resultsDone
 
-	// print stats
-	go f.processStats(3 * time.Second)
+	close(f.statsQueue)
+	<-statsDone
 
-	// start results
-	go f.saveResults()
+	if runCtx.Err() == nil {
+		log.Info("fuzzer processed all",
+			zap.Int("total", f.stats.Total),
+			zap.Int("errors", f.stats.Errors),
+			zap.Int("processed", f.stats.Processed),
+		)
+	}
+
+	return
+}
 
+// produce generates jobs from word lists and dispatches them to workers, jobs
+// before position and processed jobs after it are skipped
+func (f *Fuzzer) produce(ctx context.Context, lists []source, position int, processed []int) (err error) {
 	// enable limiter
+	var limiter <-chan time.Time
 	if f.MaxReqSec > 0 {
-		go func() {
-			interval := 1000 / f.MaxReqSec
+		ticker := time.NewTicker(time.Second / time.Duration(f.MaxReqSec))
+		defer ticker.Stop()
 
-			for {
-				if !shouldWork {
-					return
-				}
+		limiter = ticker.C
+	}
 
-				f.burstyLimiter <- true
-				time.Sleep(time.Duration(interval) * time.Millisecond)
-			}
-		}()
+	keywords := make([]string, 0, len(f.WordLists))
+	for _, w := range f.WordLists {
+		keywords = append(keywords, w.Keyword)
 	}
 
-	for {
-		f.mutex.Lock()
-		if !shouldWork {
-			f.mutex.Unlock()
-			return
+	isProcessed := make(map[int]bool, len(processed))
+	for _, i := range processed {
+		isProcessed[i] = true
+	}
+
+	index := -1
+
+	// send dispatches job built by build, jobs before position are already
+	// processed and they are not built at all
+	send := func(build func() job) bool {
+		index++
+		if index < position || isProcessed[index] {
+			return true
 		}
-		f.mutex.Unlock()
 
-		line, err := rd.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				shouldWork = false
-				return
+		// rate limit requests
+		if limiter != nil {
+			select {
+			case <-limiter:
+			case <-ctx.Done():
+				return false
 			}
+		}
 
-			log.Error("error in reading line from file",
-				zap.Error(err),
-			)
-			shouldWork = false
-			return
+		if !f.IgnoreRateLimit && !f.throttle.wait(ctx) {
+			return false
 		}
 
-		line = strings.ReplaceAll(line, "\r", "")
-		line = strings.ReplaceAll(line, "\n", "")
-		line = strings.ReplaceAll(line, "\t", "")
-		line = strings.Trim(line, " ")
+		if !f.waitIfPaused(ctx) {
+			return false
+		}
 
-		u := strings.ReplaceAll(f.URL, "FUZZ", line)
+		j := build()
+		j.index = index
 
-		// rate limit requests
-		if f.MaxReqSec > 0 {
-			<-f.burstyLimiter
+		f.recursion.dispatched()
+		select {
+		case f.jobs <- j:
+			return true
+		case <-ctx.Done():
+			f.recursion.processed()
+			return false
 		}
+	}
 
-		f.jobs <- job{
-			URL: u,
+	for n := 0; ctx.Err() == nil; n++ {
+		// jobs in flight can schedule new scans, so fuzzer ends when
+		// there are no scans left and all jobs are processed
+		s, ok := f.recursion.wait(ctx)
+		if !ok {
+			break
 		}
-	}
-}
 
-func (f *Fuzzer) Wait() (err error) {
-	defer func() {
-		f.setError(f.err)
-		err = f.err
-	}()
+		// word lists are read again for every found directory
+		if n > 0 {
+			for _, l := range lists {
+				err = l.reset()
+				if err != nil {
+					return
+				}
+			}
+		}
 
-	for {
-		f.mutex.Lock()
-		if f.totalWorkers == 0 {
-			f.mutex.Unlock()
-			return
+		// params scan sends batches of parameter names
+		var batch []string
+		sendBatch := func() bool {
+			names := batch
+			batch = nil
+			return send(func() job {
+				return f.newParamsJob(names)
+			})
 		}
-		f.mutex.Unlock()
 
-		time.Sleep(1 * time.Second)
-	}
-}
+		start := index
+		err = iterate(f.Mode, keywords, lists, func(input map[string]string) bool {
+			// every word is requested in all variants
+			for _, v := range f.variants {
+				v := v
+
+				if f.ScanType == ScanTypeParams {
+					batch = append(batch, f.extend(input, v)[keywords[0]])
+					if len(batch) == f.ParamsBatchSize && !sendBatch() {
+						return false
+					}
+					continue
+				}
 
-func (f *Fuzzer) setError(err error) {
-	f.mutex.Lock()
-	defer f.mutex.Unlock()
+				if !send(func() job { return f.newJob(s, input, v) }) {
+					return false
+				}
+			}
 
-	f.err = err
-}
+			return true
+		})
+		if err != nil {
+			return
+		}
 
-// Stop sends intent to all workers to stop
-func (f *Fuzzer) Stop() {
-	for i := 0; i < f.maxWorkers; i++ {
-		f.control <- true
+		if len(batch) > 0 && !sendBatch() {
+			break
+		}
+
+		// all words are read, so total is known even for streamed word lists
+		if n == 0 && ctx.Err() == nil {
+			f.setTotal(index-start, false)
+		}
 	}
 
-	// fan in
-	f.control <- true
+	return
+}
 
-	// results worker
-	f.control <- true
+// sleep sleeps for duration, false is returned if ctx is canceled in the meantime
+func sleep(ctx context.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return ctx.Err() == nil
+	}
 
-	// results stats
-	f.control <- true
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
 
-	f.Wait()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
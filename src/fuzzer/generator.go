@@ -0,0 +1,340 @@
+package fuzzer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDateFormat is format of dates in date generator
+const defaultDateFormat = "2006-01-02"
+
+// generatorPattern matches generator definition in place of word list path,
+// name(arguments)
+var generatorPattern = regexp.MustCompile(`^([a-z0-9]+)\((.*)\)$`)
+
+// generator is source which generates words lazily by index, so total is
+// always known and it can be reset
+type generator struct {
+	total int
+	index int
+	at    func(index int) string
+}
+
+// isGenerator checks if word list path is generator definition
+func isGenerator(path string) bool {
+	return generatorPattern.MatchString(path)
+}
+
+// newGenerator creates generator from definition:
+//
+//	range(from,to[,step[,padding]])          1, 2, ... or 0001, 0002, ...
+//	charset(chars,min,max)                   all words of chars with length min to max
+//	date(from,to[,format[,step]])            dates from 2006-01-02 to 2006-01-02 by step, 24h by default,
+//	                                         format can contain comma, Jan 2, 2006
+//	uuid1(sample,window[,step])              UUID v1 of sample with time moved by +-window
+func newGenerator(spec string) (g *generator, err error) {
+	m := generatorPattern.FindStringSubmatch(spec)
+	if m == nil {
+		err = fmt.Errorf("invalid generator %s", spec)
+		return
+	}
+
+	name, arguments := m[1], m[2]
+	switch name {
+	case "range":
+		g, err = newRangeGenerator(arguments)
+	case "charset":
+		g, err = newCharsetGenerator(arguments)
+	case "date":
+		g, err = newDateGenerator(arguments)
+	case "uuid1":
+		g, err = newUUIDGenerator(arguments)
+	default:
+		err = fmt.Errorf("unknown generator %s", name)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("invalid generator %s: %w", spec, err)
+	}
+
+	return
+}
+
+func (g *generator) next() (word string, err error) {
+	if g.index >= g.total {
+		err = io.EOF
+		return
+	}
+
+	word = g.at(g.index)
+	g.index++
+	return
+}
+
+func (g *generator) reset() error {
+	g.index = 0
+	return nil
+}
+
+func (g *generator) estimate() int {
+	return g.total
+}
+
+func (g *generator) close() {}
+
+// splitArguments splits arguments of generator and checks their number
+func splitArguments(arguments string, min, max int) (res []string, err error) {
+	res = strings.Split(arguments, ",")
+	if len(res) < min || len(res) > max {
+		err = fmt.Errorf("expected %d to %d arguments, got %d", min, max, len(res))
+	}
+
+	return
+}
+
+// steps calculates number of steps of size step needed to cover span, both
+// ends included
+func steps(span, step int64) (total int, err error) {
+	if step <= 0 {
+		err = fmt.Errorf("step must be positive")
+		return
+	}
+
+	if span < 0 {
+		err = fmt.Errorf("end must not be before start")
+		return
+	}
+
+	n := span/step + 1
+	if n > math.MaxInt32 {
+		err = fmt.Errorf("too many words, %d", n)
+		return
+	}
+
+	total = int(n)
+	return
+}
+
+func newRangeGenerator(arguments string) (g *generator, err error) {
+	args, err := splitArguments(arguments, 2, 4)
+	if err != nil {
+		return
+	}
+
+	values := []int64{0, 0, 1, 0}
+	for i, arg := range args {
+		values[i], err = strconv.ParseInt(strings.TrimSpace(arg), 10, 64)
+		if err != nil {
+			return
+		}
+	}
+	from, to, step, padding := values[0], values[1], values[2], int(values[3])
+
+	total, err := steps(to-from, step)
+	if err != nil {
+		return
+	}
+
+	g = &generator{
+		total: total,
+		at: func(index int) string {
+			return fmt.Sprintf("%0*d", padding, from+int64(index)*step)
+		},
+	}
+	return
+}
+
+func newCharsetGenerator(arguments string) (g *generator, err error) {
+	// chars can contain comma, so lengths are parsed from the end
+	idx := strings.LastIndex(arguments, ",")
+	if idx < 0 {
+		err = fmt.Errorf("expected chars,min,max")
+		return
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(arguments[idx+1:]))
+	if err != nil {
+		return
+	}
+
+	arguments = arguments[:idx]
+	idx = strings.LastIndex(arguments, ",")
+	if idx <= 0 {
+		err = fmt.Errorf("expected chars,min,max")
+		return
+	}
+	min, err := strconv.Atoi(strings.TrimSpace(arguments[idx+1:]))
+	if err != nil {
+		return
+	}
+
+	chars := []rune(arguments[:idx])
+	if min < 1 || max < min {
+		err = fmt.Errorf("invalid length %d-%d", min, max)
+		return
+	}
+
+	// offsets holds index of first word of every length
+	offsets := make([]int64, 0, max-min+2)
+	total := int64(0)
+	for length := min; length <= max; length++ {
+		offsets = append(offsets, total)
+
+		words := int64(1)
+		for i := 0; i < length; i++ {
+			words *= int64(len(chars))
+			if words > math.MaxInt32 {
+				err = fmt.Errorf("too many words, length %d", length)
+				return
+			}
+		}
+
+		total += words
+		if total > math.MaxInt32 {
+			err = fmt.Errorf("too many words, length %d", length)
+			return
+		}
+	}
+	offsets = append(offsets, total)
+
+	g = &generator{
+		total: int(total),
+		at: func(index int) string {
+			i := int64(index)
+
+			length := min
+			for i >= offsets[length-min+1] {
+				length++
+			}
+			i -= offsets[length-min]
+
+			// word is index in base of number of chars, first char changes slowest
+			word := make([]rune, length)
+			for p := length - 1; p >= 0; p-- {
+				word[p] = chars[i%int64(len(chars))]
+				i /= int64(len(chars))
+			}
+
+			return string(word)
+		},
+	}
+	return
+}
+
+func newDateGenerator(arguments string) (g *generator, err error) {
+	// format can contain comma, so from and to are parsed from the start and
+	// step from the end, last argument is step only if it is duration
+	args := strings.SplitN(arguments, ",", 3)
+	if len(args) < 2 {
+		err = fmt.Errorf("expected from,to[,format[,step]]")
+		return
+	}
+
+	from, err := time.Parse(defaultDateFormat, strings.TrimSpace(args[0]))
+	if err != nil {
+		return
+	}
+
+	to, err := time.Parse(defaultDateFormat, strings.TrimSpace(args[1]))
+	if err != nil {
+		return
+	}
+
+	format := defaultDateFormat
+	step := 24 * time.Hour
+	if len(args) > 2 {
+		format = args[2]
+
+		idx := strings.LastIndex(format, ",")
+		if idx >= 0 {
+			d, e := time.ParseDuration(strings.TrimSpace(format[idx+1:]))
+			if e == nil {
+				format, step = format[:idx], d
+			}
+		}
+
+		if format == "" {
+			format = defaultDateFormat
+		}
+	}
+
+	total, err := steps(int64(to.Sub(from)), int64(step))
+	if err != nil {
+		return
+	}
+
+	g = &generator{
+		total: total,
+		at: func(index int) string {
+			return from.Add(time.Duration(index) * step).Format(format)
+		},
+	}
+	return
+}
+
+func newUUIDGenerator(arguments string) (g *generator, err error) {
+	args, err := splitArguments(arguments, 2, 3)
+	if err != nil {
+		return
+	}
+
+	sample, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(args[0]), "-", ""))
+	if err != nil {
+		return
+	}
+
+	if len(sample) != 16 || sample[6]>>4 != 1 {
+		err = fmt.Errorf("sample %s is not UUID v1", args[0])
+		return
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(args[1]))
+	if err != nil {
+		return
+	}
+
+	step := 100 * time.Nanosecond
+	if len(args) > 2 {
+		step, err = time.ParseDuration(strings.TrimSpace(args[2]))
+		if err != nil {
+			return
+		}
+	}
+
+	// UUID v1 time is counted in 100ns intervals
+	ticks := int64(step / 100)
+	total, err := steps(2*int64(window/100), ticks)
+	if err != nil {
+		return
+	}
+
+	timestamp := int64(sample[6]&0x0F)<<56 | int64(sample[7])<<48 |
+		int64(sample[4])<<40 | int64(sample[5])<<32 |
+		int64(sample[0])<<24 | int64(sample[1])<<16 | int64(sample[2])<<8 | int64(sample[3])
+	start := timestamp - int64(window/100)
+	if start < 0 {
+		err = fmt.Errorf("window %s is before start of UUID time", window)
+		return
+	}
+
+	g = &generator{
+		total: total,
+		at: func(index int) string {
+			t := start + int64(index)*ticks
+
+			u := make([]byte, 16)
+			copy(u, sample)
+			u[0], u[1], u[2], u[3] = byte(t>>24), byte(t>>16), byte(t>>8), byte(t)
+			u[4], u[5] = byte(t>>40), byte(t>>32)
+			u[6], u[7] = 0x10|byte(t>>56)&0x0F, byte(t>>48)
+
+			return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+		},
+	}
+	return
+}
@@ -0,0 +1,200 @@
+package fuzzer
+
+import (
+	"encoding/hex"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generate reads all words of generator
+func generate(t *testing.T, spec string) (words []string) {
+	t.Helper()
+
+	g, err := newGenerator(spec)
+	if err != nil {
+		t.Fatalf("newGenerator(%s) error: %v", spec, err)
+	}
+
+	for {
+		word, err := g.next()
+		if err == io.EOF {
+			break
+		}
+		words = append(words, word)
+	}
+
+	if len(words) != g.estimate() {
+		t.Errorf("%s: generated %d words, total is %d", spec, len(words), g.estimate())
+	}
+
+	return
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		spec  string
+		words []string
+	}{
+		{"range(1,5)", []string{"1", "2", "3", "4", "5"}},
+		{"range(1,10,3)", []string{"1", "4", "7", "10"}},
+		{"range(0,9,2)", []string{"0", "2", "4", "6", "8"}},
+		{"range(8,11,1,3)", []string{"008", "009", "010", "011"}},
+		{"range(-2,1)", []string{"-2", "-1", "0", "1"}},
+		{"range(7,7)", []string{"7"}},
+
+		// shorter words first, first char changes slowest
+		{"charset(ab,1,2)", []string{"a", "b", "aa", "ab", "ba", "bb"}},
+		{"charset(xyz,2,2)", []string{"xx", "xy", "xz", "yx", "yy", "yz", "zx", "zy", "zz"}},
+		{"charset(a,,1,1)", []string{"a", ","}},
+
+		{"date(2024-02-27,2024-03-01)", []string{"2024-02-27", "2024-02-28", "2024-02-29", "2024-03-01"}},
+		{"date(2024-01-01,2024-01-02,2006-01-02T15,12h)", []string{"2024-01-01T00", "2024-01-01T12", "2024-01-02T00"}},
+		{"date(2023-12-31,2024-01-01,20060102)", []string{"20231231", "20240101"}},
+
+		// format with comma, step is taken from the end only if it is duration
+		{"date(2024-01-01,2024-01-02,Jan 2, 2006)", []string{"Jan 1, 2024", "Jan 2, 2024"}},
+		{"date(2024-01-01,2024-01-02,Jan 2, 2006,24h)", []string{"Jan 1, 2024", "Jan 2, 2024"}},
+		{"date(2024-01-01,2024-01-01,Mon, 02 Jan 2006 15:04,6h)", []string{"Mon, 01 Jan 2024 00:00"}},
+		{"date(2024-01-01,2024-01-02,,48h)", []string{"2024-01-01"}},
+	}
+
+	for _, tt := range tests {
+		words := generate(t, tt.spec)
+		if !reflect.DeepEqual(words, tt.words) {
+			t.Errorf("%s = %v, want %v", tt.spec, words, tt.words)
+		}
+	}
+}
+
+func TestCharsetGeneratorLengths(t *testing.T) {
+	words := generate(t, "charset(abc,2,4)")
+
+	// 9 words of length 2, 27 of length 3 and 81 of length 4
+	if len(words) != 9+27+81 {
+		t.Fatalf("generated %d words, want %d", len(words), 9+27+81)
+	}
+
+	boundaries := map[int]string{
+		0:   "aa",
+		8:   "cc",
+		9:   "aaa",
+		35:  "ccc",
+		36:  "aaaa",
+		37:  "aaab",
+		116: "cccc",
+	}
+	for index, want := range boundaries {
+		if words[index] != want {
+			t.Errorf("word %d = %s, want %s", index, words[index], want)
+		}
+	}
+
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		if seen[w] {
+			t.Errorf("word %s is generated twice", w)
+		}
+		seen[w] = true
+	}
+}
+
+func TestDateGeneratorRoundTrip(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	words := generate(t, "date(2024-01-01,2024-01-03,2006-01-02 15:04,90m)")
+
+	// 48h in steps of 90m, both ends included
+	if len(words) != 33 {
+		t.Fatalf("generated %d words, want 33", len(words))
+	}
+
+	for i, w := range words {
+		date, err := time.Parse("2006-01-02 15:04", w)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := from.Add(time.Duration(i) * 90 * time.Minute)
+		if !date.Equal(want) {
+			t.Errorf("word %d = %s, want %s", i, date, want)
+		}
+	}
+}
+
+// uuidTime extracts 60 bit timestamp of UUID v1
+func uuidTime(t *testing.T, uuid string) int64 {
+	t.Helper()
+
+	u, err := hex.DecodeString(strings.ReplaceAll(uuid, "-", ""))
+	if err != nil || len(u) != 16 {
+		t.Fatalf("invalid UUID %s", uuid)
+	}
+
+	return int64(u[6]&0x0F)<<56 | int64(u[7])<<48 |
+		int64(u[4])<<40 | int64(u[5])<<32 |
+		int64(u[0])<<24 | int64(u[1])<<16 | int64(u[2])<<8 | int64(u[3])
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	sample := "c232ab00-9414-11ec-b3c8-9f6bdeced846"
+	words := generate(t, "uuid1("+sample+",1s,1ms)")
+
+	// 2s window in 1ms steps, both ends included
+	if len(words) != 2001 {
+		t.Fatalf("generated %d words, want 2001", len(words))
+	}
+
+	// sample is in the middle of window
+	if words[1000] != sample {
+		t.Errorf("word 1000 = %s, want %s", words[1000], sample)
+	}
+
+	start := uuidTime(t, sample) - int64(time.Second/100)
+	for i, w := range words {
+		if len(w) != len(sample) || w[14] != '1' {
+			t.Errorf("word %d = %s is not UUID v1", i, w)
+		}
+
+		// clock sequence and node are kept
+		if w[19:] != sample[19:] {
+			t.Errorf("word %d = %s, clock and node differ from %s", i, w, sample)
+		}
+
+		want := start + int64(i)*int64(time.Millisecond/100)
+		if got := uuidTime(t, w); got != want {
+			t.Errorf("word %d = %s, time %d, want %d", i, w, got, want)
+		}
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []string{
+		"range(1)",
+		"range(1,2,3,4,5)",
+		"range(5,1)",
+		"range(1,5,0)",
+		"range(a,5)",
+		"charset(ab,0,2)",
+		"charset(ab,3,2)",
+		"charset(ab,2)",
+		"charset(abcdefghij,1,20)",
+		"date(2024-01-02,2024-01-01)",
+		"date(2024-13-01,2024-01-01)",
+		"date(2024-01-01)",
+		"date(2024-01-01,2024-01-02,,-1h)",
+		"date(2024-01-01,2024-01-02,2006,0s)",
+		"uuid1(550e8400-e29b-41d4-a716-446655440000,1s)",
+		"uuid1(c232ab00-9414-11ec-b3c8,1s)",
+		"uuid1(c232ab00-9414-11ec-b3c8-9f6bdeced846,x)",
+		"unknown(1,2)",
+	}
+
+	for _, spec := range tests {
+		_, err := newGenerator(spec)
+		if err == nil {
+			t.Errorf("newGenerator(%s) expected error", spec)
+		}
+	}
+}
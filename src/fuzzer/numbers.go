@@ -0,0 +1,142 @@
+package fuzzer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// numberRange is inclusive range of numbers
+type numberRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// numbers is set of ranges parsed from expression such as 403,500-599,>10000,5xx
+type numbers []numberRange
+
+func (n numbers) contains(value int) bool {
+	for _, r := range n {
+		if value >= r.Min && value <= r.Max {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseNumbers parses comma separated expression of numbers, supported tokens are
+// exact numbers (404), ranges (500-599), comparisons (>10000, <=50) and
+// class shorthands (5xx)
+func ParseNumbers(input string) (res numbers, err error) {
+	res = make(numbers, 0)
+
+	for _, token := range strings.Split(input, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		var r numberRange
+		r, err = parseNumberToken(token)
+		if err != nil {
+			err = fmt.Errorf("invalid token %q: %w", token, err)
+			return
+		}
+
+		res = append(res, r)
+	}
+
+	return
+}
+
+func parseNumberToken(token string) (r numberRange, err error) {
+	var value int
+
+	switch {
+	case strings.HasPrefix(token, ">="):
+		value, err = strconv.Atoi(token[2:])
+		r = numberRange{Min: value, Max: math.MaxInt}
+
+	case strings.HasPrefix(token, "<="):
+		value, err = strconv.Atoi(token[2:])
+		r = numberRange{Min: math.MinInt, Max: value}
+
+	case strings.HasPrefix(token, ">"):
+		value, err = strconv.Atoi(token[1:])
+		r = numberRange{Min: value + 1, Max: math.MaxInt}
+
+	case strings.HasPrefix(token, "<"):
+		value, err = strconv.Atoi(token[1:])
+		r = numberRange{Min: math.MinInt, Max: value - 1}
+
+	case strings.HasSuffix(strings.ToLower(token), "x"):
+		r, err = parseNumberClass(strings.ToLower(token))
+
+	case strings.Index(token, "-") > 0:
+		idx := strings.Index(token, "-")
+		r.Min, err = strconv.Atoi(token[:idx])
+		if err != nil {
+			return
+		}
+
+		r.Max, err = strconv.Atoi(token[idx+1:])
+		if err != nil {
+			return
+		}
+
+		if r.Min > r.Max {
+			err = fmt.Errorf("range start %d is greater than end %d", r.Min, r.Max)
+		}
+
+	default:
+		value, err = strconv.Atoi(token)
+		r = numberRange{Min: value, Max: value}
+	}
+
+	return
+}
+
+// parseNumberClass parses shorthand such as 5xx into 500-599
+func parseNumberClass(token string) (r numberRange, err error) {
+	idx := strings.Index(token, "x")
+	if idx == 0 || strings.Trim(token[idx:], "x") != "" {
+		err = fmt.Errorf("class must be in format such as 5xx")
+		return
+	}
+
+	prefix, err := strconv.Atoi(token[:idx])
+	if err != nil {
+		return
+	}
+
+	scale := int(math.Pow10(len(token) - idx))
+	r.Min = prefix * scale
+	r.Max = r.Min + scale - 1
+	return
+}
+
+// GetUniqueNumbers parses unique exact numbers separated by delimiter, invalid
+// numbers are skipped.
+//
+// Deprecated: fields of Filters and Matchers are expressions parsed by
+// ParseNumbers, input such as "403,404" can be set on them directly.
+func GetUniqueNumbers(input, delimiter string) (res []int) {
+	res = make([]int, 0)
+
+	unique := make(map[int]bool)
+	for _, token := range strings.Split(input, delimiter) {
+		r, err := ParseNumbers(token)
+		if err != nil || len(r) != 1 || r[0].Min != r[0].Max {
+			continue
+		}
+
+		if !unique[r[0].Min] {
+			unique[r[0].Min] = true
+			res = append(res, r[0].Min)
+		}
+	}
+
+	return
+}
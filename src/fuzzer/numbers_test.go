@@ -0,0 +1,97 @@
+package fuzzer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  numbers
+	}{
+		{"", numbers{}},
+		{"404", numbers{{404, 404}}},
+		{"403, 404", numbers{{403, 403}, {404, 404}}},
+		{"500-599", numbers{{500, 599}}},
+		{"7-7", numbers{{7, 7}}},
+		{"5xx", numbers{{500, 599}}},
+		{"4XX", numbers{{400, 499}}},
+		{"1x", numbers{{10, 19}}},
+		{">10000", numbers{{10001, math.MaxInt}}},
+		{">=10000", numbers{{10000, math.MaxInt}}},
+		{"<5", numbers{{math.MinInt, 4}}},
+		{"<=5", numbers{{math.MinInt, 5}}},
+		{"200,3xx,>=1000,,", numbers{{200, 200}, {300, 399}, {1000, math.MaxInt}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNumbers(tt.input)
+		if err != nil {
+			t.Errorf("ParseNumbers(%q) error: %v", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseNumbers(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseNumbersErrors(t *testing.T) {
+	tests := []string{
+		"abc",
+		"404,abc",
+		"599-500",
+		"5-",
+		"1-x2",
+		"x",
+		"xx",
+		"5x5",
+		"a5xx",
+		">",
+		">=",
+		"<a",
+		"<=1.5",
+	}
+
+	for _, input := range tests {
+		_, err := ParseNumbers(input)
+		if err == nil {
+			t.Errorf("ParseNumbers(%q) expected error", input)
+		}
+	}
+}
+
+func TestNumbersContains(t *testing.T) {
+	n, err := ParseNumbers("200,3xx,>=1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[int]bool{
+		199:  false,
+		200:  true,
+		201:  false,
+		300:  true,
+		399:  true,
+		400:  false,
+		999:  false,
+		1000: true,
+	}
+
+	for value, want := range tests {
+		if got := n.contains(value); got != want {
+			t.Errorf("contains(%d) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestGetUniqueNumbers(t *testing.T) {
+	got := GetUniqueNumbers("404,403,x,404,5xx,403", ",")
+	want := []int{404, 403}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetUniqueNumbers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package fuzzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultParamsBatchSize is number of parameters sent in one request
+	DefaultParamsBatchSize = 32
+
+	// ParamsLocationQuery sends parameters in query string
+	ParamsLocationQuery = "query"
+
+	// ParamsLocationBody sends parameters in form encoded body
+	ParamsLocationBody = "body"
+)
+
+// validateParams prepares params scan, words of word list are parameter names
+// which are sent in batches, baseline is learned in calibration
+func (f *Fuzzer) validateParams() (err error) {
+	if len(f.WordLists) != 1 {
+		err = errors.New("params scan requires exactly one word list")
+		return
+	}
+
+	if f.Recursion || len(f.Extensions) > 0 {
+		err = errors.New("recursion and extensions can't be used in params scan")
+		return
+	}
+
+	if f.ParamsBatchSize < 0 {
+		err = errors.New("params batch size can't be negative")
+		return
+	}
+
+	if f.ParamsBatchSize == 0 {
+		f.ParamsBatchSize = DefaultParamsBatchSize
+	}
+
+	switch f.ParamsLocation {
+	case "":
+		f.ParamsLocation = ParamsLocationQuery
+
+	case ParamsLocationQuery:
+
+	case ParamsLocationBody:
+		if !f.hasHeader("Content-Type") {
+			f.setHeader("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+	default:
+		err = fmt.Errorf("unknown params location %s", f.ParamsLocation)
+		return
+	}
+
+	f.paramValue = randomToken()
+	f.AutoCalibrate = true
+	return
+}
+
+// newParamsJob renders request with parameters
+func (f *Fuzzer) newParamsJob(names []string) (j job) {
+	j = f.newJob(f.rootScan(), nil, variant{})
+	j.params = names
+
+	if len(names) == 1 {
+		j.Input = map[string]string{
+			f.WordLists[0].Keyword: names[0],
+		}
+	}
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, url.QueryEscape(name)+"="+f.paramValue)
+	}
+	query := strings.Join(pairs, "&")
+
+	switch f.ParamsLocation {
+	case ParamsLocationBody:
+		if len(j.Body) > 0 {
+			j.Body = append(j.Body, '&')
+		}
+		j.Body = append(j.Body, query...)
+
+	default:
+		separator := "?"
+		if strings.Contains(j.URL, "?") {
+			separator = "&"
+		}
+		j.URL += separator + query
+	}
+
+	return
+}
+
+// calibrationParams returns random parameter names for calibration request,
+// baseline alternates full batch and single parameter, since response can
+// depend on number of parameters
+func (f *Fuzzer) calibrationParams(i int) []string {
+	size := f.ParamsBatchSize
+	if i%2 == 1 {
+		size = 1
+	}
+
+	names := make([]string, 0, size)
+	for n := 0; n < size; n++ {
+		names = append(names, randomToken())
+	}
+
+	return names
+}
+
+// mine bisects batch of parameters which changes response against baseline,
+// until single parameters are isolated, false is returned if it is interrupted
+func (f *Fuzzer) mine(ctx context.Context, j job, r *response) bool {
+	if f.isCalibrated(r) {
+		return true
+	}
+
+	if len(j.params) == 1 {
+		f.save(j, r)
+		return true
+	}
+
+	half := len(j.params) / 2
+	for _, names := range [][]string{j.params[:half], j.params[half:]} {
+		sub := f.newParamsJob(names)
+		sub.index = j.index
+
+		r, err := f.send(ctx, sub)
+		if ctx.Err() != nil {
+			return false
+		}
+
+		if err != nil {
+			f.Log.Warn("error in bisecting parameters",
+				zap.Strings("params", names),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if !f.mine(ctx, sub, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeFailedParams writes names of failed batch, so they can be re-run
+func (f *Fuzzer) writeFailedParams(j job) {
+	for _, name := range j.params {
+		f.failed.write(map[string]string{
+			f.WordLists[0].Keyword: name,
+		})
+	}
+}
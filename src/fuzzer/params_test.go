@@ -0,0 +1,139 @@
+package fuzzer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParamsScan(t *testing.T) {
+	var duplicateHeaders int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Values("Content-Type")) > 1 {
+			atomic.AddInt32(&duplicateHeaders, 1)
+		}
+
+		params := r.URL.Query()
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			params, _ = url.ParseQuery(string(body))
+		}
+
+		// only debug parameter changes response, other names are reflected
+		if params.Get("debug") != "" {
+			fmt.Fprintf(w, "admin panel\nsecret\n")
+			return
+		}
+		fmt.Fprintf(w, "hello %s\n", strings.Join(sortedKeys(params), ","))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordList := filepath.Join(dir, "params.txt")
+	words := "id\nuser\npage\nsort\nlimit\noffset\nq\ndebug\nlang\ntoken\n"
+	err := os.WriteFile(wordList, []byte(words), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		location string
+		method   string
+		headers  http.Header
+	}{
+		{ParamsLocationQuery, http.MethodGet, nil},
+
+		// header defined in lower case isn't sent twice
+		{ParamsLocationBody, http.MethodPost, http.Header{"content-type": {"application/x-www-form-urlencoded"}}},
+		{ParamsLocationBody, http.MethodPost, nil},
+	}
+
+	for _, tt := range tests {
+		outFile := filepath.Join(dir, "out.json")
+
+		f, err := New(&Config{
+			URL:             server.URL + "/",
+			Method:          tt.method,
+			Headers:         tt.headers,
+			ScanType:        ScanTypeParams,
+			ParamsBatchSize: 4,
+			ParamsLocation:  tt.location,
+			WordList:        wordList,
+			OutFile:         outFile,
+			Threads:         2,
+			IsSilent:        true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = f.Run(ctx)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 10 parameters in batches of 4
+		if f.stats.Total != 3 {
+			t.Errorf("%s: total %d, want 3 batches", tt.location, f.stats.Total)
+		}
+
+		found := readParams(t, outFile)
+		if len(found) != 1 || found[0] != "debug" {
+			t.Errorf("%s: found %v, want [debug]", tt.location, found)
+		}
+	}
+
+	if n := atomic.LoadInt32(&duplicateHeaders); n > 0 {
+		t.Errorf("%d requests with duplicate Content-Type header", n)
+	}
+}
+
+func sortedKeys(values url.Values) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return
+}
+
+// readParams reads found parameters from out file, header is skipped
+func readParams(t *testing.T, path string) (params []string) {
+	t.Helper()
+
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		var r Result
+		err = json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.URL == "" {
+			continue
+		}
+		params = append(params, r.Input[DefaultKeyword])
+	}
+
+	return
+}
@@ -0,0 +1,92 @@
+package fuzzer
+
+import (
+	"context"
+	"time"
+)
+
+// Pause pauses dispatch of jobs until Resume is called, requests which are
+// already sent are finished
+func (f *Fuzzer) Pause() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.isPaused {
+		return
+	}
+
+	f.isPaused = true
+	f.unpaused = make(chan struct{})
+
+	if !f.IsSilent {
+		f.Log.Warn("fuzzer paused")
+	}
+
+	f.sendStateEvent(Event{
+		Type:        EventTypePaused,
+		Description: "paused",
+		Value:       true,
+	})
+}
+
+// Resume resumes dispatch of jobs paused by Pause
+func (f *Fuzzer) Resume() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.isPaused {
+		return
+	}
+
+	f.isPaused = false
+	close(f.unpaused)
+
+	if !f.IsSilent {
+		f.Log.Warn("fuzzer resumed")
+	}
+
+	f.sendStateEvent(Event{
+		Type:        EventTypeResumed,
+		Description: "resumed",
+		Value:       false,
+	})
+}
+
+// IsPaused checks if fuzzer is paused
+func (f *Fuzzer) IsPaused() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.isPaused
+}
+
+// sendStateEvent sends event if fuzzer is not finished, mutex must be held
+func (f *Fuzzer) sendStateEvent(event Event) {
+	if f.isFinished {
+		return
+	}
+
+	select {
+	case f.Events <- event:
+	case <-time.After(1 * time.Millisecond):
+	}
+}
+
+// waitIfPaused blocks while fuzzer is paused, false is returned if ctx is canceled
+func (f *Fuzzer) waitIfPaused(ctx context.Context) bool {
+	f.mutex.Lock()
+	unpaused := f.unpaused
+	isPaused := f.isPaused
+	f.mutex.Unlock()
+
+	if !isPaused {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-unpaused:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
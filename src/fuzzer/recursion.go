@@ -0,0 +1,216 @@
+package fuzzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRecursionDepth is maximum depth of recursion if it is not set
+const DefaultRecursionDepth = 2
+
+// scan is one pass over word lists, recursion schedules scan for every found
+// directory
+type scan struct {
+	// URL is URL template of scan, it ends with keyword of first word list
+	URL string `json:"url"`
+
+	// Depth is depth of recursion, root scan has depth 0
+	Depth int `json:"depth"`
+}
+
+// recursion schedules scans and tracks jobs in flight, since they can find
+// directories and schedule new scans
+type recursion struct {
+	mutex *sync.Mutex
+
+	// scans are all scheduled scans in order, they are saved in checkpoint,
+	// so jobs get the same index when fuzzer is resumed
+	scans []scan
+
+	// next is index of next scan to produce
+	next int
+
+	// seen holds URLs of already scheduled scans
+	seen map[string]bool
+
+	// pending is number of jobs dispatched, which are not processed yet
+	pending int
+
+	// changed is signaled when scan is scheduled or all jobs are processed
+	changed chan struct{}
+}
+
+func newRecursion(scans []scan) *recursion {
+	r := &recursion{
+		mutex:   &sync.Mutex{},
+		scans:   scans,
+		seen:    make(map[string]bool, len(scans)),
+		changed: make(chan struct{}, 1),
+	}
+
+	for _, s := range scans {
+		r.seen[s.URL] = true
+	}
+
+	return r
+}
+
+func (r *recursion) signal() {
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// schedule schedules scan unless it is already scheduled
+func (r *recursion) schedule(s scan) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.seen[s.URL] {
+		return false
+	}
+	r.seen[s.URL] = true
+	r.scans = append(r.scans, s)
+
+	r.signal()
+	return true
+}
+
+// wait returns next scan, if all scheduled scans are produced it waits until
+// jobs in flight are processed, since they can schedule new scans
+func (r *recursion) wait(ctx context.Context) (s scan, ok bool) {
+	for {
+		r.mutex.Lock()
+		if r.next < len(r.scans) {
+			s = r.scans[r.next]
+			r.next++
+			r.mutex.Unlock()
+			return s, true
+		}
+
+		pending := r.pending
+		r.mutex.Unlock()
+
+		if pending == 0 {
+			return
+		}
+
+		select {
+		case <-r.changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatched marks job as in flight
+func (r *recursion) dispatched() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.pending++
+}
+
+// processed marks job as processed
+func (r *recursion) processed() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.pending--
+	if r.pending == 0 {
+		r.signal()
+	}
+}
+
+// get returns copy of scheduled scans
+func (r *recursion) get() []scan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]scan(nil), r.scans...)
+}
+
+func (r *recursion) size() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.scans)
+}
+
+// validateRecursion checks that found directories can be appended to URL
+func (f *Fuzzer) validateRecursion() (err error) {
+	if !f.Recursion {
+		return
+	}
+
+	if f.RecursionDepth <= 0 {
+		f.RecursionDepth = DefaultRecursionDepth
+	}
+
+	keyword := f.WordLists[0].Keyword
+	if !strings.HasSuffix(f.URL, keyword) {
+		err = fmt.Errorf("recursion requires URL ending with keyword %s", keyword)
+		return
+	}
+
+	return
+}
+
+// rootScan is scan of URL defined in config
+func (f *Fuzzer) rootScan() scan {
+	// names of dns scan are rendered as URL of job
+	if f.ScanType == ScanTypeDNS {
+		return scan{
+			URL: f.Domain,
+		}
+	}
+
+	return scan{
+		URL: f.URL,
+	}
+}
+
+// isDirectory checks if response looks like directory, either request is
+// redirected to the same URL with trailing slash, or URL with trailing slash
+// is found
+func isDirectory(j job, r *response) bool {
+	if r.RedirectLocation == j.URL+"/" {
+		return r.StatusCode != http.StatusNotFound
+	}
+
+	if strings.HasSuffix(j.URL, "/") {
+		return r.StatusCode == http.StatusOK || r.StatusCode == http.StatusForbidden
+	}
+
+	return false
+}
+
+// recurse schedules scan of directory found by job
+func (f *Fuzzer) recurse(j job, r *response) {
+	if !f.Recursion || j.depth >= f.RecursionDepth || !isDirectory(j, r) {
+		return
+	}
+
+	directory := j.URL
+	if !strings.HasSuffix(directory, "/") {
+		directory += "/"
+	}
+
+	s := scan{
+		URL:   directory + f.WordLists[0].Keyword,
+		Depth: j.depth + 1,
+	}
+
+	if f.recursion.schedule(s) && !f.IsSilent {
+		f.Log.Info("scheduled scan of found directory",
+			zap.String("url", s.URL),
+			zap.Int("depth", s.Depth),
+		)
+	}
+}
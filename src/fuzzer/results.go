@@ -3,99 +3,59 @@ package fuzzer
 import (
 	"encoding/json"
 	"os"
-	"strconv"
-	"strings"
-	"time"
-
-	"go.uber.org/zap"
 )
 
 type Result struct {
-	RedirectLocation string `json:"redirectLocation"`
-	URL              string `json:"url"`
-	Size             int    `json:"size"`
-	Lines            int    `json:"lines"`
-	StatusCode       int    `json:"statusCode"`
-	Words            int    `json:"words"`
+	RedirectLocation string            `json:"redirectLocation"`
+	URL              string            `json:"url"`
+	Method           string            `json:"method"`
+	Host             string            `json:"host,omitempty"`
+	Input            map[string]string `json:"input"`
+	Payload          map[string]string `json:"payload,omitempty"`
+	Rule             string            `json:"rule,omitempty"`
+	Extension        string            `json:"extension,omitempty"`
+	Depth            int               `json:"depth"`
+	Size             int               `json:"size"`
+	Lines            int               `json:"lines"`
+	StatusCode       int               `json:"statusCode"`
+	Words            int               `json:"words"`
+	Matched          []string          `json:"matched,omitempty"`
+	Addresses        []string          `json:"addresses,omitempty"`
 }
 
-// saveResults is worker which saves results one by one in jsonl format
-func (f *Fuzzer) saveResults() {
-	fd, err := os.OpenFile(f.OutFile, os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		f.totalWorkers--
+// header is written as first line of out file, it describes what fuzzer
+// learned before start
+type header struct {
+	Calibration []fingerprint `json:"calibration,omitempty"`
 
-		f.Log.Error("error in opening out file",
-			zap.Error(err),
-		)
-		return
-	}
+	// Wildcard holds addresses of wildcard DNS records
+	Wildcard []string `json:"wildcard,omitempty"`
+}
+
+// saveResults is worker which saves results one by one in jsonl format,
+// until results channel is closed
+func (f *Fuzzer) saveResults(fd *os.File) {
 	defer fd.Close()
 
 	defer func() {
-		f.mutex.Lock()
-		defer f.mutex.Unlock()
-
 		fd.Sync()
 
 		if !f.IsSilent {
-			f.Log.Debug("shutting down results worker",
-				zap.Int("totalWorkers", f.totalWorkers),
-			)
+			f.Log.Debug("shutting down results worker")
 		}
-
-		f.totalWorkers--
 	}()
 
-	shouldWork := true
-
-	// monitoring for control exit
-	go func() {
-		<-f.control
-
-		f.mutex.Lock()
-		defer f.mutex.Unlock()
-
-		shouldWork = false
-	}()
-
-	for {
-		f.mutex.Lock()
-		if !shouldWork {
-			f.mutex.Unlock()
-			return
-		}
-		f.mutex.Unlock()
-
-		var r Result
-		select {
-		case r = <-f.results:
-		case <-time.After(3 * time.Second):
-			continue
-		}
-
-		raw, _ := json.Marshal(r)
+	// resumed out file already has header
+	if (len(f.calibration) > 0 || len(f.wildcard) > 0) && f.resumed == nil {
+		raw, _ := json.Marshal(header{
+			Calibration: f.calibration,
+			Wildcard:    f.wildcardAddresses(),
+		})
 		fd.WriteString(string(raw) + "\n")
 	}
-}
-
-func GetUniqueNumbers(input, delimiter string) (res []int) {
-	res = make([]int, 0)
-
-	tmp := strings.Split(input, delimiter)
-	unq := make(map[int]bool, 0)
-	for _, t := range tmp {
-		val, err := strconv.Atoi(t)
-		if err != nil {
-			continue
-		}
-
-		unq[val] = true
-	}
 
-	for key := range unq {
-		res = append(res, key)
+	for r := range f.results {
+		raw, _ := json.Marshal(r)
+		fd.WriteString(string(raw) + "\n")
 	}
-
-	return
 }
@@ -0,0 +1,117 @@
+package fuzzer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// retryBaseDelay is delay before first retry, it doubles with every next retry
+	retryBaseDelay = 500 * time.Millisecond
+
+	// retryMaxDelay caps delay between retries
+	retryMaxDelay = 30 * time.Second
+)
+
+// isTransient checks if request failed for reason which is likely to go away on retry
+func isTransient(r *response, err error) bool {
+	if err == nil {
+		return r.StatusCode == http.StatusBadGateway || r.StatusCode == http.StatusGatewayTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// resolver failed to answer, SERVFAIL
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsTemporary {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns exponential delay with jitter for given retry attempt
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	// full jitter over upper half of delay
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// failedWriter writes words of permanently failed jobs, so they can be re-run.
+// Single word list is written into one file, multiple word lists are written
+// into file per keyword (path.KEYWORD), which can be re-run in pitchfork mode
+type failedWriter struct {
+	mutex *sync.Mutex
+	files map[string]*os.File
+}
+
+// openFailedWriter opens failed files, they are appended to if fuzzer is resumed
+func openFailedWriter(path string, lists []WordList, isResumed bool) (w *failedWriter, err error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if isResumed {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	w = &failedWriter{
+		mutex: &sync.Mutex{},
+		files: make(map[string]*os.File, len(lists)),
+	}
+
+	for _, l := range lists {
+		name := path
+		if len(lists) > 1 {
+			name = fmt.Sprintf("%s.%s", path, l.Keyword)
+		}
+
+		var fd *os.File
+		fd, err = os.OpenFile(name, flags, 0644)
+		if err != nil {
+			w.close()
+			err = fmt.Errorf("error in opening failed file %s: %w", name, err)
+			return
+		}
+
+		w.files[l.Keyword] = fd
+	}
+
+	return
+}
+
+func (w *failedWriter) write(input map[string]string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for keyword, fd := range w.files {
+		fd.WriteString(input[keyword] + "\n")
+	}
+}
+
+func (w *failedWriter) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for _, fd := range w.files {
+		fd.Sync()
+		fd.Close()
+	}
+}
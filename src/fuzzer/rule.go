@@ -0,0 +1,438 @@
+package fuzzer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ruleFunction mutates word, functions with position out of word leave word unchanged
+type ruleFunction func(word []rune) []rune
+
+// rule is one line of rule file, functions are applied one after another
+type rule struct {
+	text      string
+	functions []ruleFunction
+}
+
+func (r *rule) apply(word string) string {
+	if r == nil {
+		return word
+	}
+
+	w := []rune(word)
+	for _, f := range r.functions {
+		w = f(w)
+	}
+
+	return string(w)
+}
+
+// loadRules loads hashcat style rule file, empty lines and lines starting
+// with # are skipped
+func loadRules(path string) (rules []*rule, err error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("error in opening rule file %s: %w", path, err)
+		return
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var r *rule
+		r, err = parseRule(line)
+		if err != nil {
+			err = fmt.Errorf("error in rule file %s, line %d: %w", path, n, err)
+			return
+		}
+		rules = append(rules, r)
+	}
+
+	err = scanner.Err()
+	if err == nil && len(rules) == 0 {
+		err = fmt.Errorf("rule file %s has no rules", path)
+	}
+
+	return
+}
+
+// rulePosition converts hashcat position, 0-9 and A-Z for 10-35
+func rulePosition(c rune) (n int, err error) {
+	switch {
+	case '0' <= c && c <= '9':
+		n = int(c - '0')
+	case 'A' <= c && c <= 'Z':
+		n = int(c-'A') + 10
+	default:
+		err = fmt.Errorf("invalid position %q", c)
+	}
+
+	return
+}
+
+// parseRule parses subset of hashcat rule functions:
+//
+//	:      nothing              l, u   lower, upper case
+//	c, C   capitalize, invert   t, TN  toggle case of all, of position N
+//	r      reverse              d, pN  duplicate, duplicate N times
+//	f      reflect              {, }   rotate left, right
+//	$X, ^X append, prepend X    [, ]   delete first, last
+//	DN     delete at N          'N     truncate at N
+//	xNM    extract M from N     ONM    omit M from N
+//	iNX    insert X at N        oNX    overwrite N with X
+//	sXY    replace X with Y     @X     purge X
+//	zN, ZN duplicate first, last N   q  duplicate every character
+//	k, K   swap first, last two *NM  swap N and M
+func parseRule(line string) (r *rule, err error) {
+	r = &rule{
+		text: line,
+	}
+	input := []rune(line)
+
+	// arguments reads n arguments of function at i
+	i := 0
+	arguments := func(n int) (args []rune, err error) {
+		if i+n >= len(input) {
+			err = fmt.Errorf("function %q requires %d arguments", input[i], n)
+			return
+		}
+		args = input[i+1 : i+1+n]
+		i += n
+		return
+	}
+
+	for ; i < len(input); i++ {
+		op := input[i]
+		var (
+			f    ruleFunction
+			args []rune
+		)
+
+		switch op {
+		case ' ', '\t':
+			continue
+
+		case ':':
+			f = func(w []rune) []rune { return w }
+
+		case 'l':
+			f = func(w []rune) []rune { return []rune(strings.ToLower(string(w))) }
+
+		case 'u':
+			f = func(w []rune) []rune { return []rune(strings.ToUpper(string(w))) }
+
+		case 'c', 'C':
+			upperFirst := op == 'c'
+			f = func(w []rune) []rune {
+				for p := range w {
+					if (p == 0) == upperFirst {
+						w[p] = unicode.ToUpper(w[p])
+					} else {
+						w[p] = unicode.ToLower(w[p])
+					}
+				}
+				return w
+			}
+
+		case 't':
+			f = func(w []rune) []rune {
+				for p := range w {
+					w[p] = toggleCase(w[p])
+				}
+				return w
+			}
+
+		case 'r':
+			f = func(w []rune) []rune {
+				for a, b := 0, len(w)-1; a < b; a, b = a+1, b-1 {
+					w[a], w[b] = w[b], w[a]
+				}
+				return w
+			}
+
+		case 'd':
+			f = func(w []rune) []rune { return append(w, w...) }
+
+		case 'f':
+			f = func(w []rune) []rune {
+				res := append([]rune{}, w...)
+				for p := len(w) - 1; p >= 0; p-- {
+					res = append(res, w[p])
+				}
+				return res
+			}
+
+		case '{':
+			f = func(w []rune) []rune {
+				if len(w) == 0 {
+					return w
+				}
+				return append(w[1:], w[0])
+			}
+
+		case '}':
+			f = func(w []rune) []rune {
+				if len(w) == 0 {
+					return w
+				}
+				return append([]rune{w[len(w)-1]}, w[:len(w)-1]...)
+			}
+
+		case '[':
+			f = func(w []rune) []rune {
+				if len(w) == 0 {
+					return w
+				}
+				return w[1:]
+			}
+
+		case ']':
+			f = func(w []rune) []rune {
+				if len(w) == 0 {
+					return w
+				}
+				return w[:len(w)-1]
+			}
+
+		case 'q':
+			f = func(w []rune) []rune {
+				res := make([]rune, 0, len(w)*2)
+				for _, c := range w {
+					res = append(res, c, c)
+				}
+				return res
+			}
+
+		case 'k':
+			f = func(w []rune) []rune {
+				if len(w) >= 2 {
+					w[0], w[1] = w[1], w[0]
+				}
+				return w
+			}
+
+		case 'K':
+			f = func(w []rune) []rune {
+				if n := len(w); n >= 2 {
+					w[n-2], w[n-1] = w[n-1], w[n-2]
+				}
+				return w
+			}
+
+		case '$', '^', '@':
+			args, err = arguments(1)
+			if err != nil {
+				return
+			}
+			c := args[0]
+
+			switch op {
+			case '$':
+				f = func(w []rune) []rune { return append(w, c) }
+			case '^':
+				f = func(w []rune) []rune { return append([]rune{c}, w...) }
+			case '@':
+				f = func(w []rune) []rune {
+					res := w[:0]
+					for _, x := range w {
+						if x != c {
+							res = append(res, x)
+						}
+					}
+					return res
+				}
+			}
+
+		case 's':
+			args, err = arguments(2)
+			if err != nil {
+				return
+			}
+			from, to := args[0], args[1]
+			f = func(w []rune) []rune {
+				for p := range w {
+					if w[p] == from {
+						w[p] = to
+					}
+				}
+				return w
+			}
+
+		case 'T', 'p', 'D', '\'', 'z', 'Z':
+			args, err = arguments(1)
+			if err != nil {
+				return
+			}
+
+			var n int
+			n, err = rulePosition(args[0])
+			if err != nil {
+				return
+			}
+			f = positionFunction(op, n)
+
+		case 'x', 'O', '*':
+			args, err = arguments(2)
+			if err != nil {
+				return
+			}
+
+			var n, m int
+			n, err = rulePosition(args[0])
+			if err != nil {
+				return
+			}
+			m, err = rulePosition(args[1])
+			if err != nil {
+				return
+			}
+			f = rangeFunction(op, n, m)
+
+		case 'i', 'o':
+			args, err = arguments(2)
+			if err != nil {
+				return
+			}
+
+			var n int
+			n, err = rulePosition(args[0])
+			if err != nil {
+				return
+			}
+			c := args[1]
+
+			if op == 'i' {
+				f = func(w []rune) []rune {
+					if n > len(w) {
+						return w
+					}
+					res := append([]rune{}, w[:n]...)
+					res = append(res, c)
+					return append(res, w[n:]...)
+				}
+			} else {
+				f = func(w []rune) []rune {
+					if n < len(w) {
+						w[n] = c
+					}
+					return w
+				}
+			}
+
+		default:
+			err = fmt.Errorf("unsupported function %q", op)
+			return
+		}
+
+		r.functions = append(r.functions, f)
+	}
+
+	return
+}
+
+// positionFunction creates functions with one position argument
+func positionFunction(op rune, n int) ruleFunction {
+	switch op {
+	case 'T':
+		return func(w []rune) []rune {
+			if n < len(w) {
+				w[n] = toggleCase(w[n])
+			}
+			return w
+		}
+
+	case 'p':
+		return func(w []rune) []rune {
+			res := append([]rune{}, w...)
+			for k := 0; k < n; k++ {
+				res = append(res, w...)
+			}
+			return res
+		}
+
+	case 'D':
+		return func(w []rune) []rune {
+			if n >= len(w) {
+				return w
+			}
+			return append(w[:n], w[n+1:]...)
+		}
+
+	case '\'':
+		return func(w []rune) []rune {
+			if n >= len(w) {
+				return w
+			}
+			return w[:n]
+		}
+
+	case 'z':
+		return func(w []rune) []rune {
+			if len(w) == 0 {
+				return w
+			}
+			res := make([]rune, 0, len(w)+n)
+			for k := 0; k < n; k++ {
+				res = append(res, w[0])
+			}
+			return append(res, w...)
+		}
+	}
+
+	// Z
+	return func(w []rune) []rune {
+		if len(w) == 0 {
+			return w
+		}
+		last := w[len(w)-1]
+		for k := 0; k < n; k++ {
+			w = append(w, last)
+		}
+		return w
+	}
+}
+
+// rangeFunction creates functions with two position arguments
+func rangeFunction(op rune, n, m int) ruleFunction {
+	switch op {
+	case 'x':
+		return func(w []rune) []rune {
+			if n+m > len(w) {
+				return w
+			}
+			return w[n : n+m]
+		}
+
+	case 'O':
+		return func(w []rune) []rune {
+			if n+m > len(w) {
+				return w
+			}
+			return append(w[:n], w[n+m:]...)
+		}
+	}
+
+	// *
+	return func(w []rune) []rune {
+		if n < len(w) && m < len(w) {
+			w[n], w[m] = w[m], w[n]
+		}
+		return w
+	}
+}
+
+func toggleCase(c rune) rune {
+	if unicode.IsUpper(c) {
+		return unicode.ToLower(c)
+	}
+
+	return unicode.ToUpper(c)
+}
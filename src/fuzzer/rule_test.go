@@ -0,0 +1,150 @@
+package fuzzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	// expected outputs are the ones of hashcat
+	tests := []struct {
+		rule string
+		word string
+		want string
+	}{
+		{":", "p@ssW0rd", "p@ssW0rd"},
+		{"l", "p@ssW0rd", "p@ssw0rd"},
+		{"u", "p@ssW0rd", "P@SSW0RD"},
+		{"c", "p@ssW0rd", "P@ssw0rd"},
+		{"C", "p@ssW0rd", "p@SSW0RD"},
+		{"t", "p@ssW0rd", "P@SSw0RD"},
+		{"T3", "p@ssW0rd", "p@sSW0rd"},
+		{"r", "p@ssW0rd", "dr0Wss@p"},
+		{"d", "p@ssW0rd", "p@ssW0rdp@ssW0rd"},
+		{"p2", "p@ssW0rd", "p@ssW0rdp@ssW0rdp@ssW0rd"},
+		{"f", "p@ssW0rd", "p@ssW0rddr0Wss@p"},
+		{"{", "p@ssW0rd", "@ssW0rdp"},
+		{"}", "p@ssW0rd", "dp@ssW0r"},
+		{"$1", "p@ssW0rd", "p@ssW0rd1"},
+		{"^1", "p@ssW0rd", "1p@ssW0rd"},
+		{"[", "p@ssW0rd", "@ssW0rd"},
+		{"]", "p@ssW0rd", "p@ssW0r"},
+		{"D3", "p@ssW0rd", "p@sW0rd"},
+		{"x04", "p@ssW0rd", "p@ss"},
+		{"O12", "p@ssW0rd", "psW0rd"},
+		{"i4!", "p@ssW0rd", "p@ss!W0rd"},
+		{"o3$", "p@ssW0rd", "p@s$W0rd"},
+		{"'6", "p@ssW0rd", "p@ssW0"},
+		{"ss$", "p@ssW0rd", "p@$$W0rd"},
+		{"@s", "p@ssW0rd", "p@W0rd"},
+		{"z2", "p@ssW0rd", "ppp@ssW0rd"},
+		{"Z2", "p@ssW0rd", "p@ssW0rddd"},
+		{"q", "p@ssW0rd", "pp@@ssssWW00rrdd"},
+		{"k", "p@ssW0rd", "@pssW0rd"},
+		{"K", "p@ssW0rd", "p@ssW0dr"},
+		{"*34", "p@ssW0rd", "p@sWs0rd"},
+
+		// functions are applied in order, spaces are ignored
+		{"c $1 $2", "password", "Password12"},
+		{"sa@ so0 ^!", "password", "!p@ssw0rd"},
+		{"r c", "password", "Drowssap"},
+
+		// positions above 9 are letters
+		{"TA", "abcdefghijkl", "abcdefghijKl"},
+		{"'A", "abcdefghijkl", "abcdefghij"},
+
+		// positions out of word leave word unchanged
+		{"T9", "abc", "abc"},
+		{"D9", "abc", "abc"},
+		{"'9", "abc", "abc"},
+		{"x25", "abc", "abc"},
+		{"O25", "abc", "abc"},
+		{"i9X", "abc", "abc"},
+		{"o9X", "abc", "abc"},
+		{"*09", "abc", "abc"},
+		{"i3X", "abc", "abcX"},
+
+		// empty word
+		{"{", "", ""},
+		{"]", "", ""},
+		{"z2", "", ""},
+		{"k", "a", "a"},
+	}
+
+	for _, tt := range tests {
+		r, err := parseRule(tt.rule)
+		if err != nil {
+			t.Errorf("parseRule(%q) error: %v", tt.rule, err)
+			continue
+		}
+
+		got := r.apply(tt.word)
+		if got != tt.want {
+			t.Errorf("parseRule(%q).apply(%q) = %q, want %q", tt.rule, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []string{
+		"$",
+		"^",
+		"@",
+		"s",
+		"sa",
+		"T",
+		"D",
+		"x1",
+		"O1",
+		"*1",
+		"i1",
+		"o1",
+		"Tg",
+		"x1g",
+		"ig!",
+		"Y",
+		"c Y",
+	}
+
+	for _, rule := range tests {
+		_, err := parseRule(rule)
+		if err == nil {
+			t.Errorf("parseRule(%q) expected error", rule)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.rule")
+	err := os.WriteFile(path, []byte("# comment\n:\n\nc\r\n$1 $2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := loadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{":", "c", "$1 $2"}
+	if len(rules) != len(want) {
+		t.Fatalf("loadRules() loaded %d rules, want %d", len(rules), len(want))
+	}
+
+	for i, r := range rules {
+		if r.text != want[i] {
+			t.Errorf("rule %d = %q, want %q", i, r.text, want[i])
+		}
+	}
+
+	err = os.WriteFile(path, []byte(":\nY\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = loadRules(path)
+	if err == nil {
+		t.Error("loadRules() expected error for unsupported function")
+	}
+}
@@ -0,0 +1,37 @@
+package fuzzer
+
+import "fmt"
+
+const (
+	// ScanTypeHTTP fuzzes positions of HTTP request
+	ScanTypeHTTP = "http"
+
+	// ScanTypeVHost fuzzes Host header against fixed target, responses of
+	// default virtual host are filtered out
+	ScanTypeVHost = "vhost"
+
+	// ScanTypeParams discovers hidden parameters, words are parameter names
+	ScanTypeParams = "params"
+
+	// ScanTypeDNS enumerates subdomains, names are resolved instead of
+	// sending HTTP requests
+	ScanTypeDNS = "dns"
+)
+
+func (f *Fuzzer) validateScanType() (err error) {
+	switch f.ScanType {
+	case "":
+		f.ScanType = ScanTypeHTTP
+	case ScanTypeHTTP:
+	case ScanTypeVHost:
+		err = f.validateVHost()
+	case ScanTypeParams:
+		err = f.validateParams()
+	case ScanTypeDNS:
+		err = f.validateDNS()
+	default:
+		err = fmt.Errorf("unknown scan type %s", f.ScanType)
+	}
+
+	return
+}
@@ -16,15 +16,69 @@ type stats struct {
 	LastProcessed  int       `json:"lastProcessed"`
 	ReqPerSec      float64   `json:"reqPerSec"`
 	Total          int       `json:"total"`
-	Processed      int       `json:"processed"`
-	Errors         int       `json:"errors"`
-	Saved          int       `json:"saved"`
+	// IsTotalEstimated is set while total is estimated from word list sizes
+	IsTotalEstimated bool `json:"isTotalEstimated"`
+	Processed        int  `json:"processed"`
+	Errors           int  `json:"errors"`
+	Saved            int  `json:"saved"`
+	Throttled        int  `json:"throttled"`
+	Retried          int  `json:"retried"`
+	Failed           int  `json:"failed"`
+}
+
+// estimateTotal estimates total number of jobs from sizes of word lists, word
+// lists aren't read twice, exact total is set when first scan reads them all
+func (f *Fuzzer) estimateTotal(sources []source) {
+	counts := make([]int, len(sources))
+	for i, s := range sources {
+		counts[i] = s.estimate()
+	}
+	f.setTotal(f.countJobs(counts), true)
+}
+
+func (f *Fuzzer) setTotal(total int, isEstimated bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.total = total
+	f.isTotalEstimated = isEstimated && total != unknownTotal
+}
+
+// syncTotal copies total counted in background into stats
+func (f *Fuzzer) syncTotal() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// total is counted per scan, recursion scans word lists multiple times
+	f.stats.Total = f.total
+	if f.total != unknownTotal {
+		f.stats.Total *= f.recursion.size()
+	}
+	f.stats.IsTotalEstimated = f.isTotalEstimated
+}
+
+// formatTotal formats total for humans, ~ marks estimated and ? unknown total
+func (s *stats) formatTotal() string {
+	switch {
+	case s.Total == unknownTotal:
+		return "?"
+	case s.IsTotalEstimated:
+		return fmt.Sprintf("~%d", s.Total)
+	}
+
+	return fmt.Sprintf("%d", s.Total)
 }
 
 func (f *Fuzzer) calculateStats() {
+	f.syncTotal()
+
 	duration := time.Since(f.Started)
 	seconds := duration.Seconds()
-	reqPerSec := float64(f.stats.Processed) / float64(seconds)
+	processed := f.stats.Processed
+	if f.resumed != nil {
+		processed -= f.resumed.Position
+	}
+	reqPerSec := float64(processed) / float64(seconds)
 
 	// add throughput
 	event := Event{
@@ -40,7 +94,7 @@ func (f *Fuzzer) calculateStats() {
 	// add progress
 	event = Event{
 		Type:        EventTypeProgress,
-		Description: fmt.Sprintf("%d / %d", f.stats.Processed, f.stats.Total),
+		Description: fmt.Sprintf("%d / %s", f.stats.Processed, f.stats.formatTotal()),
 		Value:       f.stats.Processed,
 	}
 	select {
@@ -55,14 +109,24 @@ func (f *Fuzzer) calculateStats() {
 
 func (f *Fuzzer) PrintStats() {
 	if !f.IsSilent {
+		// left is unknown until total is known
+		left := unknownTotal
+		if f.stats.Total != unknownTotal {
+			left = f.stats.Total - f.stats.Processed
+		}
+
 		logger.Log.Info("stats",
 			zap.String("url", f.URL),
 			zap.String("proxyURL", f.ProxyURL),
 			zap.Int("total", f.stats.Total),
+			zap.Bool("isTotalEstimated", f.stats.IsTotalEstimated),
 			zap.Int("processed", f.stats.Processed),
-			zap.Int("left", f.stats.Total-f.stats.Processed),
+			zap.Int("left", left),
 			zap.Int("saved", f.stats.Saved),
 			zap.Int("errors", f.stats.Errors),
+			zap.Int("throttled", f.stats.Throttled),
+			zap.Int("retried", f.stats.Retried),
+			zap.Int("failed", f.stats.Failed),
 			zap.Int("totalJobs", len(f.jobs)),
 			zap.Int("totalEvents", len(f.Events)),
 			zap.Int("maxWorkers", f.maxWorkers),
@@ -72,22 +136,28 @@ func (f *Fuzzer) PrintStats() {
 	}
 }
 
+// processStats collects stats until stats queue is closed
 func (f *Fuzzer) processStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	defer func() {
+		f.calculateStats()
+		f.PrintStats()
+		f.saveCheckpoint()
+
+		if !f.IsSilent {
+			f.Log.Debug("shutting down stats")
+		}
+	}()
+
 	for {
 		select {
-		case <-f.control:
-			f.mutex.Lock()
-			defer f.mutex.Unlock()
-
-			if !f.IsSilent {
-				f.Log.Debug("shutting down stats",
-					zap.Int("totalWorkers", f.totalWorkers),
-				)
+		case s, ok := <-f.statsQueue:
+			if !ok {
+				return
 			}
-			f.totalWorkers--
-			return
 
-		case s := <-f.statsQueue:
 			switch s {
 			case "processed":
 				f.stats.Processed += 1
@@ -97,6 +167,15 @@ func (f *Fuzzer) processStats(interval time.Duration) {
 
 			case "saved":
 				f.stats.Saved += 1
+
+			case "throttled":
+				f.stats.Throttled += 1
+
+			case "retried":
+				f.stats.Retried += 1
+
+			case "failed":
+				f.stats.Failed += 1
 			}
 
 			if time.Since(f.stats.LastCalculated) > interval {
@@ -104,9 +183,10 @@ func (f *Fuzzer) processStats(interval time.Duration) {
 				f.PrintStats()
 			}
 
-		case <-time.After(interval):
+		case <-ticker.C:
 			f.calculateStats()
 			f.PrintStats()
+			f.saveCheckpoint()
 		}
 	}
 }
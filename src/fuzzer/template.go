@@ -0,0 +1,69 @@
+package fuzzer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dpanic/fuzzer/src/request"
+)
+
+// DefaultRequestScheme is scheme of request file, raw request doesn't define it
+const DefaultRequestScheme = "https"
+
+// loadRequestFile sets method, URL, headers and body from raw request file,
+// headers from config are added to headers of request file
+func (f *Fuzzer) loadRequestFile() (err error) {
+	if f.RequestFile == "" {
+		return
+	}
+
+	if f.URL != "" {
+		err = errors.New("target URL and request file can't be used together")
+		return
+	}
+
+	raw, err := os.ReadFile(f.RequestFile)
+	if err != nil {
+		err = fmt.Errorf("error in reading request file %s: %w", f.RequestFile, err)
+		return
+	}
+
+	r, err := request.ParseRaw(string(raw))
+	if err != nil {
+		err = fmt.Errorf("error in parsing request file %s: %w", f.RequestFile, err)
+		return
+	}
+
+	switch f.RequestScheme {
+	case "":
+		f.RequestScheme = DefaultRequestScheme
+	case "http", "https":
+	default:
+		err = fmt.Errorf("unknown request scheme %s", f.RequestScheme)
+		return
+	}
+
+	f.URL, err = r.URL(f.RequestScheme, f.RequestHost)
+	if err != nil {
+		err = fmt.Errorf("error in request file %s: %w", f.RequestFile, err)
+		return
+	}
+
+	if r.Body != "" {
+		if f.Body != "" {
+			err = errors.New("body and request file with body can't be used together")
+			return
+		}
+		f.Body = r.Body
+	}
+
+	f.Method = r.Method
+
+	for name, values := range f.Headers {
+		r.Headers[name] = append(r.Headers[name], values...)
+	}
+	f.Headers = r.Headers
+
+	return
+}
@@ -0,0 +1,55 @@
+package fuzzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestFileWithRecursion(t *testing.T) {
+	dir := t.TempDir()
+
+	requestFile := filepath.Join(dir, "req.txt")
+	err := os.WriteFile(requestFile, []byte("GET /admin/FUZZ HTTP/1.1\r\nHost: example.com\r\nX-Token: abc\r\n\r\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wordList := filepath.Join(dir, "words.txt")
+	err = os.WriteFile(wordList, []byte("a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := New(&Config{
+		RequestFile: requestFile,
+		WordList:    wordList,
+		OutFile:     filepath.Join(dir, "out.json"),
+		Recursion:   true,
+		IsSilent:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.URL != "https://example.com/admin/FUZZ" || f.Method != "GET" || f.Headers.Get("X-Token") != "abc" {
+		t.Errorf("url %s, method %s, headers %v", f.URL, f.Method, f.Headers)
+	}
+
+	// request line without keyword at the end can't be scanned recursively
+	err = os.WriteFile(requestFile, []byte("GET /FUZZ.php HTTP/1.1\r\nHost: example.com\r\n\r\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New(&Config{
+		RequestFile: requestFile,
+		WordList:    wordList,
+		OutFile:     filepath.Join(dir, "out.json"),
+		Recursion:   true,
+		IsSilent:    true,
+	})
+	if err == nil {
+		t.Error("expected error for recursion of URL which doesn't end with keyword")
+	}
+}
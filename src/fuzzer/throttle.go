@@ -0,0 +1,157 @@
+package fuzzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultRetryAfter is used when server doesn't send Retry-After header
+	defaultRetryAfter = 5 * time.Second
+
+	// maxRetryAfter caps pause requested by server
+	maxRetryAfter = 5 * time.Minute
+
+	// maxThrottleRequeues is number of times job is requeued while server is
+	// throttling it, so path which is always throttled doesn't block worker
+	maxThrottleRequeues = 5
+
+	// minThrottleDelay and maxThrottleDelay are bounds of delay between dispatched jobs
+	minThrottleDelay = 50 * time.Millisecond
+	maxThrottleDelay = 10 * time.Second
+)
+
+// errThrottled is returned when job is still throttled after maxThrottleRequeues
+var errThrottled = errors.New("server kept throttling request")
+
+// throttle pauses dispatch of jobs when server responds with 429 or 503 and
+// slowly ramps rate back up with every successful response
+type throttle struct {
+	mutex *sync.Mutex
+
+	// until defines time until which dispatch is paused
+	until time.Time
+
+	// delay defines delay between dispatched jobs
+	delay time.Duration
+}
+
+func newThrottle() *throttle {
+	return &throttle{
+		mutex: &sync.Mutex{},
+	}
+}
+
+// isThrottled checks if response asks client to slow down
+func isThrottled(r *response) bool {
+	switch r.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return r.Headers.Get("Retry-After") != ""
+	}
+
+	return false
+}
+
+// parseRetryAfter parses Retry-After header given in seconds or as HTTP date
+func parseRetryAfter(value string) (wait time.Duration) {
+	wait = defaultRetryAfter
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = time.Until(date)
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+
+	return
+}
+
+// slowDown pauses dispatch for wait and doubles delay between jobs
+func (t *throttle) slowDown(wait time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	until := time.Now().Add(wait)
+	if until.After(t.until) {
+		t.until = until
+	}
+
+	t.delay *= 2
+	if t.delay < minThrottleDelay {
+		t.delay = minThrottleDelay
+	}
+
+	if t.delay > maxThrottleDelay {
+		t.delay = maxThrottleDelay
+	}
+}
+
+// speedUp decreases delay between jobs
+func (t *throttle) speedUp() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.delay == 0 {
+		return
+	}
+
+	t.delay = t.delay * 9 / 10
+	if t.delay < minThrottleDelay {
+		t.delay = 0
+	}
+}
+
+// wait blocks until dispatch is allowed, false is returned if ctx is canceled
+func (t *throttle) wait(ctx context.Context) bool {
+	t.mutex.Lock()
+	pause := time.Until(t.until)
+	if pause < 0 {
+		pause = 0
+	}
+	pause += t.delay
+	t.mutex.Unlock()
+
+	return sleep(ctx, pause)
+}
+
+// slowDown pauses dispatch as requested by throttled response
+func (f *Fuzzer) slowDown(j job, r *response) {
+	wait := parseRetryAfter(r.Headers.Get("Retry-After"))
+	f.throttle.slowDown(wait)
+
+	f.statsQueue <- "throttled"
+
+	if !f.IsSilent {
+		f.Log.Warn("server is throttling requests, pausing",
+			zap.String("url", j.URL),
+			zap.Int("statusCode", r.StatusCode),
+			zap.Duration("wait", wait),
+		)
+	}
+
+	event := Event{
+		Type:        EventTypeThrottled,
+		Description: fmt.Sprintf("status %d, paused for %s", r.StatusCode, wait),
+		Value:       wait,
+	}
+	select {
+	case f.Events <- event:
+	case <-time.After(1 * time.Millisecond):
+	}
+}
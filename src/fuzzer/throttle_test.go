@@ -0,0 +1,69 @@
+package fuzzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleRequeuesAreLimited(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			atomic.AddInt32(&requests, 1)
+		}
+
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordList := filepath.Join(dir, "words.txt")
+	err := os.WriteFile(wordList, []byte("login\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := New(&Config{
+		URL:        server.URL + "/FUZZ",
+		WordList:   wordList,
+		OutFile:    filepath.Join(dir, "out.json"),
+		FailedFile: filepath.Join(dir, "failed.txt"),
+		Threads:    1,
+		IsSilent:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = f.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != maxThrottleRequeues+1 {
+		t.Errorf("sent %d requests, want %d", got, maxThrottleRequeues+1)
+	}
+
+	if f.stats.Failed != 1 || f.stats.Throttled != maxThrottleRequeues {
+		t.Errorf("failed %d, throttled %d, want 1 and %d", f.stats.Failed, f.stats.Throttled, maxThrottleRequeues)
+	}
+
+	failed, err := os.ReadFile(filepath.Join(dir, "failed.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(failed) != "login\n" {
+		t.Errorf("failed file = %q, want %q", failed, "login\n")
+	}
+}
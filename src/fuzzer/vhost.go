@@ -0,0 +1,25 @@
+package fuzzer
+
+import "errors"
+
+// validateVHost renders Host header from VHost template, default virtual host
+// is learned in calibration
+func (f *Fuzzer) validateVHost() (err error) {
+	if f.Recursion {
+		err = errors.New("recursion can't be used in vhost scan")
+		return
+	}
+
+	if f.VHost == "" {
+		f.VHost = f.WordLists[0].Keyword
+	}
+
+	if f.hasHeader("Host") {
+		err = errors.New("host header can't be defined in vhost scan")
+		return
+	}
+
+	f.setHeader("Host", f.VHost)
+	f.AutoCalibrate = true
+	return
+}
@@ -0,0 +1,438 @@
+package fuzzer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// DefaultKeyword is keyword used when word list is defined without one
+	DefaultKeyword = "FUZZ"
+
+	// ModeClusterbomb iterates over cartesian product of all word lists
+	ModeClusterbomb = "clusterbomb"
+
+	// ModePitchfork iterates over all word lists in parallel, line by line
+	ModePitchfork = "pitchfork"
+
+	// StdinPath is word list path which reads words from stdin
+	StdinPath = "-"
+)
+
+// WordList binds word list file or generator, range(1,1000), to keyword which
+// is replaced in request
+type WordList struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+}
+
+// ParseWordList parses word list definition in format path[:KEYWORD]
+func ParseWordList(input string) (w WordList) {
+	w.Path = input
+	w.Keyword = DefaultKeyword
+
+	idx := strings.LastIndex(input, ":")
+	if idx <= 0 || idx == len(input)-1 {
+		return
+	}
+
+	// colon can be part of path or generator arguments
+	keyword := input[idx+1:]
+	if strings.ContainsAny(keyword, `/\()`) {
+		return
+	}
+
+	w.Path = input[:idx]
+	w.Keyword = keyword
+	return
+}
+
+// source produces words for one keyword
+type source interface {
+	// next returns next word, io.EOF is returned when source is exhausted
+	next() (word string, err error)
+
+	// reset rewinds source to the beginning
+	reset() error
+
+	// estimate quickly estimates number of words, unknownTotal is returned if
+	// it can't be estimated
+	estimate() int
+
+	close()
+}
+
+// unknownTotal is used when number of words can't be determined
+const unknownTotal = -1
+
+// estimateSampleSize is number of bytes read to estimate number of lines
+const estimateSampleSize = 64 << 10
+
+// errNotResettable is returned when streamed word list has to be read more than once
+var errNotResettable = errors.New("word list is streamed and can't be read more than once")
+
+// wordList is opened word list which can be read line by line, regular files
+// can be rewound, while stdin, named pipes and other streams can be read only
+// once, .gz and .zst files are decompressed on the fly
+type wordList struct {
+	WordList
+
+	fd  *os.File
+	dec io.ReadCloser
+	rd  *bufio.Reader
+
+	// size is size of regular file, unknownTotal for streams
+	size int64
+}
+
+func openWordList(w WordList) (l *wordList, err error) {
+	l = &wordList{
+		WordList: w,
+		size:     unknownTotal,
+	}
+
+	if w.Path == StdinPath {
+		l.fd = os.Stdin
+		l.dec = io.NopCloser(os.Stdin)
+		l.rd = bufio.NewReader(l.dec)
+		return
+	}
+
+	l.fd, err = os.OpenFile(w.Path, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		err = fmt.Errorf("error in opening word list %s: %w", w.Path, err)
+		return
+	}
+
+	info, err := l.fd.Stat()
+	if err != nil {
+		l.fd.Close()
+		err = fmt.Errorf("error in reading word list %s: %w", w.Path, err)
+		return
+	}
+
+	if info.Mode().IsRegular() {
+		l.size = info.Size()
+	}
+
+	l.dec, err = decompress(w.Path, l.fd)
+	if err != nil {
+		l.fd.Close()
+		err = fmt.Errorf("error in decompressing word list %s: %w", w.Path, err)
+		return
+	}
+
+	l.rd = bufio.NewReader(l.dec)
+	return
+}
+
+// isCompressed checks by extension if word list is compressed
+func isCompressed(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".zst":
+		return true
+	}
+
+	return false
+}
+
+// decompress wraps reader into decompressor chosen by extension of path,
+// uncompressed readers are returned as they are
+func decompress(path string, r io.Reader) (rc io.ReadCloser, err error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		rc, err = gzip.NewReader(r)
+
+	case ".zst":
+		var d *zstd.Decoder
+		d, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return
+		}
+		rc = d.IOReadCloser()
+
+	default:
+		rc = io.NopCloser(r)
+	}
+
+	return
+}
+
+// next returns next cleaned line from word list, io.EOF is returned when list is exhausted
+func (l *wordList) next() (line string, err error) {
+	line, err = l.rd.ReadString('\n')
+	if err != nil {
+		if err != io.EOF || line == "" {
+			return
+		}
+		err = nil
+	}
+
+	line = strings.ReplaceAll(line, "\r", "")
+	line = strings.ReplaceAll(line, "\n", "")
+	line = strings.ReplaceAll(line, "\t", "")
+	line = strings.Trim(line, " ")
+	return
+}
+
+// reset rewinds word list to the beginning, compressed lists are decompressed
+// again from the beginning
+func (l *wordList) reset() (err error) {
+	if l.size == unknownTotal {
+		return errNotResettable
+	}
+
+	_, err = l.fd.Seek(0, io.SeekStart)
+	if err != nil {
+		return
+	}
+
+	l.dec.Close()
+	l.dec, err = decompress(l.Path, l.fd)
+	if err != nil {
+		// keep reader valid, so close doesn't fail
+		l.dec = io.NopCloser(l.fd)
+		return
+	}
+
+	l.rd.Reset(l.dec)
+	return
+}
+
+// estimate estimates number of lines from file size and average line length
+// at the beginning of file, compressed lists are not estimated
+func (l *wordList) estimate() int {
+	if l.size == unknownTotal || isCompressed(l.Path) {
+		return unknownTotal
+	}
+
+	fd, err := os.Open(l.Path)
+	if err != nil {
+		return unknownTotal
+	}
+	defer fd.Close()
+
+	buf := make([]byte, estimateSampleSize)
+	n, _ := io.ReadFull(fd, buf)
+
+	lines := bytes.Count(buf[:n], []byte("\n"))
+	if int64(n) == l.size {
+		if n > 0 && buf[n-1] != '\n' {
+			lines++
+		}
+		return lines
+	}
+
+	if lines == 0 {
+		return unknownTotal
+	}
+
+	return int(l.size * int64(lines) / int64(n))
+}
+
+func (l *wordList) close() {
+	l.dec.Close()
+
+	// stdin is left open, it is owned by process
+	if l.fd != os.Stdin {
+		l.fd.Close()
+	}
+}
+
+// openSources opens word lists, in clusterbomb mode only first word list
+// can be streamed since other lists are read multiple times, if isRepeated is
+// set all lists are read multiple times
+func openSources(mode string, lists []WordList, isRepeated bool) (sources []source, err error) {
+	sources = make([]source, 0, len(lists))
+
+	for i, w := range lists {
+		if isGenerator(w.Path) {
+			var g *generator
+			g, err = newGenerator(w.Path)
+			if err != nil {
+				break
+			}
+			sources = append(sources, g)
+			continue
+		}
+
+		var l *wordList
+		l, err = openWordList(w)
+		if err != nil {
+			break
+		}
+		sources = append(sources, l)
+
+		if l.size != unknownTotal {
+			continue
+		}
+
+		if isRepeated {
+			err = fmt.Errorf("word list %s: %w, word lists can't be streamed in recursion", w.Path, errNotResettable)
+			break
+		}
+
+		if mode == ModeClusterbomb && i > 0 {
+			err = fmt.Errorf("word list %s: %w, only first word list can be streamed in clusterbomb mode", w.Path, errNotResettable)
+			break
+		}
+	}
+
+	if err != nil {
+		for _, s := range sources {
+			s.close()
+		}
+		sources = nil
+	}
+
+	return
+}
+
+// iterate generates inputs from sources bound to keywords according to mode,
+// emit returns false if iteration should stop
+func iterate(mode string, keywords []string, lists []source, emit func(input map[string]string) bool) (err error) {
+	if len(lists) == 0 {
+		return
+	}
+
+	values := make([]string, len(lists))
+	read := func(i int) (err error) {
+		values[i], err = lists[i].next()
+		return
+	}
+
+	input := func() map[string]string {
+		res := make(map[string]string, len(lists))
+		for i, keyword := range keywords {
+			res[keyword] = values[i]
+		}
+		return res
+	}
+
+	if mode == ModePitchfork {
+		for {
+			for i := range lists {
+				err = read(i)
+				if err != nil {
+					if err == io.EOF {
+						err = nil
+					}
+					return
+				}
+			}
+
+			if !emit(input()) {
+				return
+			}
+		}
+	}
+
+	// clusterbomb, first list changes slowest
+	for i := range lists {
+		err = read(i)
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+	}
+
+	for {
+		if !emit(input()) {
+			return
+		}
+
+		i := len(lists) - 1
+		for ; i >= 0; i-- {
+			err = read(i)
+			if err == nil {
+				break
+			}
+
+			if err != io.EOF || i == 0 {
+				if err == io.EOF {
+					err = nil
+				}
+				return
+			}
+
+			err = lists[i].reset()
+			if err != nil {
+				return
+			}
+
+			err = read(i)
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				return
+			}
+		}
+	}
+}
+
+// countInputs calculates total number of inputs generated by iterate
+func countInputs(mode string, counts []int) (total int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	for _, c := range counts {
+		if c == unknownTotal {
+			return unknownTotal
+		}
+	}
+
+	if mode == ModePitchfork {
+		total = counts[0]
+		for _, c := range counts[1:] {
+			if c < total {
+				total = c
+			}
+		}
+		return
+	}
+
+	total = 1
+	for _, c := range counts {
+		total *= c
+	}
+	return
+}
+
+// sortKeywords sorts keywords by length, so longer keywords are replaced first
+// and keywords which are prefixes of others (FUZZ, FUZZ2) don't collide
+func sortKeywords(lists []WordList) (keywords []string) {
+	keywords = make([]string, 0, len(lists))
+	for _, w := range lists {
+		keywords = append(keywords, w.Keyword)
+	}
+
+	sort.SliceStable(keywords, func(i, j int) bool {
+		return len(keywords[i]) > len(keywords[j])
+	})
+	return
+}
+
+// replacer creates replacer of keywords with input values, keywords are
+// replaced in single pass, so keywords inside words are kept as they are
+func (f *Fuzzer) replacer(input map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, len(f.keywords)*2)
+	for _, keyword := range f.keywords {
+		pairs = append(pairs, keyword, input[keyword])
+	}
+
+	return strings.NewReplacer(pairs...)
+}
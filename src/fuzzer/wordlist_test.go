@@ -0,0 +1,253 @@
+package fuzzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// sliceSource is in memory source of words
+type sliceSource struct {
+	words []string
+	pos   int
+}
+
+func (s *sliceSource) next() (word string, err error) {
+	if s.pos >= len(s.words) {
+		err = io.EOF
+		return
+	}
+
+	word = s.words[s.pos]
+	s.pos++
+	return
+}
+
+func (s *sliceSource) reset() error {
+	s.pos = 0
+	return nil
+}
+
+func (s *sliceSource) estimate() int {
+	return len(s.words)
+}
+
+func (s *sliceSource) close() {}
+
+func TestIterate(t *testing.T) {
+	keywords := []string{"A", "B", "C"}
+	lists := [][]string{
+		{"a1", "a2"},
+		{"b1", "b2", "b3"},
+		{"c1"},
+	}
+
+	tests := []struct {
+		mode   string
+		lists  [][]string
+		inputs []string
+	}{
+		// last list changes fastest, exhausted lists are reset
+		{ModeClusterbomb, lists, []string{
+			"a1 b1 c1", "a1 b2 c1", "a1 b3 c1",
+			"a2 b1 c1", "a2 b2 c1", "a2 b3 c1",
+		}},
+		{ModeClusterbomb, [][]string{{"a1"}, {"b1", "b2"}, {"c1", "c2", "c3"}}, []string{
+			"a1 b1 c1", "a1 b1 c2", "a1 b1 c3",
+			"a1 b2 c1", "a1 b2 c2", "a1 b2 c3",
+		}},
+		{ModeClusterbomb, [][]string{{"a1", "a2"}, {}, {"c1"}}, nil},
+
+		// shortest list stops iteration
+		{ModePitchfork, lists, []string{"a1 b1 c1"}},
+		{ModePitchfork, [][]string{{"a1", "a2", "a3"}, {"b1", "b2"}, {"c1", "c2", "c3", "c4"}}, []string{
+			"a1 b1 c1", "a2 b2 c2",
+		}},
+		{ModePitchfork, [][]string{{"a1", "a2"}, {"b1", "b2"}, {}}, nil},
+	}
+
+	for _, tt := range tests {
+		sources := make([]source, 0, len(tt.lists))
+		counts := make([]int, 0, len(tt.lists))
+		for _, words := range tt.lists {
+			s := &sliceSource{words: words}
+			sources = append(sources, s)
+			counts = append(counts, s.estimate())
+		}
+
+		var inputs []string
+		err := iterate(tt.mode, keywords, sources, func(input map[string]string) bool {
+			inputs = append(inputs, input["A"]+" "+input["B"]+" "+input["C"])
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(inputs, tt.inputs) {
+			t.Errorf("%s %v: inputs %v, want %v", tt.mode, tt.lists, inputs, tt.inputs)
+		}
+
+		if total := countInputs(tt.mode, counts); total != len(tt.inputs) {
+			t.Errorf("%s %v: counted %d inputs, generated %d", tt.mode, tt.lists, total, len(tt.inputs))
+		}
+	}
+}
+
+func TestIterateStop(t *testing.T) {
+	for _, mode := range []string{ModeClusterbomb, ModePitchfork} {
+		sources := []source{
+			&sliceSource{words: []string{"a1", "a2", "a3"}},
+			&sliceSource{words: []string{"b1", "b2", "b3"}},
+		}
+
+		emitted := 0
+		err := iterate(mode, []string{"A", "B"}, sources, func(input map[string]string) bool {
+			emitted++
+			return emitted < 2
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if emitted != 2 {
+			t.Errorf("%s: emitted %d inputs after stop, want 2", mode, emitted)
+		}
+	}
+}
+
+func TestCountInputs(t *testing.T) {
+	tests := []struct {
+		mode   string
+		counts []int
+		total  int
+	}{
+		{ModeClusterbomb, nil, 0},
+		{ModeClusterbomb, []int{5}, 5},
+		{ModeClusterbomb, []int{2, 3, 4}, 24},
+		{ModeClusterbomb, []int{2, 0, 4}, 0},
+		{ModeClusterbomb, []int{2, unknownTotal, 4}, unknownTotal},
+		{ModePitchfork, nil, 0},
+		{ModePitchfork, []int{5}, 5},
+		{ModePitchfork, []int{4, 2, 3}, 2},
+		{ModePitchfork, []int{4, 0, 3}, 0},
+		{ModePitchfork, []int{4, 2, unknownTotal}, unknownTotal},
+	}
+
+	for _, tt := range tests {
+		total := countInputs(tt.mode, tt.counts)
+		if total != tt.total {
+			t.Errorf("countInputs(%s, %v) = %d, want %d", tt.mode, tt.counts, total, tt.total)
+		}
+	}
+}
+
+func TestReplacer(t *testing.T) {
+	f := &Fuzzer{}
+	f.keywords = sortKeywords([]WordList{
+		{Keyword: "FUZZ"},
+		{Keyword: "FUZZ2"},
+		{Keyword: "USER"},
+	})
+
+	tests := []struct {
+		input    map[string]string
+		template string
+		want     string
+	}{
+		// longer keyword isn't replaced by its prefix
+		{map[string]string{"FUZZ": "a", "FUZZ2": "b", "USER": "c"}, "/FUZZ/FUZZ2/USER", "/a/b/c"},
+		{map[string]string{"FUZZ": "a", "FUZZ2": "b", "USER": "c"}, "/FUZZ2FUZZ", "/ba"},
+
+		// keywords inside words aren't replaced again
+		{map[string]string{"FUZZ": "USER", "FUZZ2": "FUZZ", "USER": "FUZZ2"}, "/FUZZ/FUZZ2/USER", "/USER/FUZZ/FUZZ2"},
+		{map[string]string{"FUZZ": "xFUZZ2x", "FUZZ2": "b", "USER": "c"}, "FUZZ&FUZZ2", "xFUZZ2x&b"},
+
+		// empty words
+		{map[string]string{"FUZZ": "", "FUZZ2": "", "USER": ""}, "a=FUZZ&b=FUZZ2", "a=&b="},
+	}
+
+	for _, tt := range tests {
+		got := f.replacer(tt.input).Replace(tt.template)
+		if got != tt.want {
+			t.Errorf("replace %q with %v = %q, want %q", tt.template, tt.input, got, tt.want)
+		}
+	}
+
+	// longer keywords are first, whatever order of word lists is
+	keywords := sortKeywords([]WordList{{Keyword: "FUZZ2"}, {Keyword: "FUZZ"}, {Keyword: "FUZZ23"}})
+	if strings.Join(keywords, ",") != "FUZZ23,FUZZ2,FUZZ" {
+		t.Errorf("sortKeywords() = %v", keywords)
+	}
+}
+
+func TestCompressedWordList(t *testing.T) {
+	words := []string{"admin", "login", "backup"}
+	plain := []byte(strings.Join(words, "\n") + "\n")
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(plain)
+	gw.Close()
+
+	var zst bytes.Buffer
+	zw, err := zstd.NewWriter(&zst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(plain)
+	zw.Close()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"words.txt":    plain,
+		"words.txt.gz": gz.Bytes(),
+		"words.TXT.GZ": gz.Bytes(),
+		"words.zst":    zst.Bytes(),
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err = os.WriteFile(path, content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := openWordList(WordList{Path: path, Keyword: DefaultKeyword})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// compressed word lists are read again after reset
+		for pass := 0; pass < 2; pass++ {
+			var got []string
+			for {
+				word, err := l.next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, word)
+			}
+
+			if !reflect.DeepEqual(got, words) {
+				t.Errorf("%s pass %d: words %v, want %v", name, pass, got, words)
+			}
+
+			err = l.reset()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		l.close()
+	}
+}
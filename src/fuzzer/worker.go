@@ -1,7 +1,8 @@
 package fuzzer
 
 import (
-	"strings"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/dpanic/fuzzer/src/request"
@@ -9,119 +10,214 @@ import (
 	"go.uber.org/zap"
 )
 
-func (f *Fuzzer) Worker(id int) {
+// Worker processes jobs until jobs channel is closed or ctx is canceled
+func (f *Fuzzer) Worker(ctx context.Context, id int) {
 	defer func() {
-		f.mutex.Lock()
-		defer f.mutex.Unlock()
-
 		if !f.IsSilent {
-			f.Log.Warn("shutting down worker",
+			f.Log.Debug("shutting down worker",
 				zap.Int("id", id),
-				zap.Int("totalWorkers", f.totalWorkers),
 			)
 		}
-
-		f.totalWorkers--
 	}()
 
-	shouldWork := true
+	for {
+		var (
+			j  job
+			ok bool
+		)
 
-	// monitoring for control exit
-	go func() {
-		<-f.control
+		select {
+		case j, ok = <-f.jobs:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
 
-		f.mutex.Lock()
-		defer f.mutex.Unlock()
+		// jobs which are already dispatched wait too, so no traffic is sent
+		if !f.waitIfPaused(ctx) {
+			return
+		}
+
+		// pause requested by server is global, it applies to queued jobs too
+		if !f.IgnoreRateLimit && !f.throttle.wait(ctx) {
+			return
+		}
+
+		f.process(ctx, j)
+		f.recursion.processed()
+	}
+}
 
-		shouldWork = false
+// process executes job with retries and saves result
+func (f *Fuzzer) process(ctx context.Context, j job) {
+	r, err := f.send(ctx, j)
+
+	// job is interrupted, it is not processed
+	if ctx.Err() != nil {
+		return
+	}
+
+	isDone := true
+	defer func() {
+		if isDone {
+			f.progress.done(j.index)
+		}
 	}()
 
-	for {
-		f.mutex.Lock()
-		if !shouldWork {
-			f.mutex.Unlock()
-			return
+	isFailed := err != nil || (f.Retries > 0 && isTransient(r, err))
+	if isFailed {
+		f.statsQueue <- "failed"
+
+		switch {
+		case f.failed == nil:
+		case f.ScanType == ScanTypeParams:
+			f.writeFailedParams(j)
+		default:
+			f.failed.write(j.Input)
 		}
-		f.mutex.Unlock()
+	}
 
-		var (
-			j job
-		)
+	if err != nil {
+		f.statsQueue <- "error"
+		f.statsQueue <- "processed"
 
+		event := Event{
+			Type:  EventTypeError,
+			Value: err.Error(),
+		}
 		select {
-		case j = <-f.jobs:
-		case <-time.After(3 * time.Second):
-			continue
+		case f.Events <- event:
+		case <-time.After(1 * time.Millisecond):
 		}
 
-		// if !f.IsSilent {
-		// 	logger.Log.Debug("received new job!",
-		// 		zap.String("url", j.URL),
-		// 	)
-		// }
+		return
+	}
 
-		var (
-			res        []byte
-			statusCode int
-			location   string
-			err        error
-			url        string
-		)
+	f.statsQueue <- "processed"
 
-		url = j.URL
-		headers := request.GetHeaders()
-		ua := request.GetUserAgent(f.UserAgent, f.PseudoRandomUserAgent)
-		headers["user-agent"] = []string{
-			ua,
-		}
+	if !f.IgnoreRateLimit {
+		f.throttle.speedUp()
+	}
 
-		if f.PreExecuteRequestTransform != nil {
-			(f.PreExecuteRequestTransform)(&url, &f.ProxyURL, &headers)
-		}
+	if isFailed {
+		return
+	}
+
+	if f.ScanType == ScanTypeDNS {
+		f.saveHost(j, r)
+		return
+	}
 
-		res, statusCode, location, err = request.Do(url, f.Method, nil, headers, f.Log)
+	// interrupted bisection is repeated when fuzzer is resumed
+	if f.ScanType == ScanTypeParams {
+		isDone = f.mine(ctx, j, r)
+		return
+	}
 
-		if err != nil {
-			f.statsQueue <- "error"
-			f.statsQueue <- "processed"
+	f.save(j, r)
+}
 
-			event := Event{
-				Type:  EventTypeError,
-				Value: err.Error(),
+// send executes job, it is retried on transient errors and requeued while
+// server is throttling requests, job which is still throttled after
+// maxThrottleRequeues fails
+func (f *Fuzzer) send(ctx context.Context, j job) (r *response, err error) {
+	r, err = f.execute(ctx, j)
+
+retry:
+	for attempt, requeues := 0, 0; ; {
+		switch {
+		case err == nil && !f.IgnoreRateLimit && isThrottled(r) && requeues >= maxThrottleRequeues:
+			err = fmt.Errorf("%w, status %d after %d requeues", errThrottled, r.StatusCode, requeues)
+			break retry
+
+		// requeue job until server stops throttling
+		case err == nil && !f.IgnoreRateLimit && isThrottled(r):
+			f.slowDown(j, r)
+			if !f.throttle.wait(ctx) {
+				return
 			}
-			select {
-			case f.Events <- event:
-			case <-time.After(1 * time.Millisecond):
+			requeues++
+
+		case attempt < f.Retries && isTransient(r, err):
+			f.statsQueue <- "retried"
+			if !sleep(ctx, backoff(attempt)) {
+				return
 			}
+			attempt++
 
-			continue
+		default:
+			break retry
 		}
 
-		lines := strings.Count(string(res), "\n")
-		words := strings.Count(string(res), " ")
+		r, err = f.execute(ctx, j)
+	}
 
-		redirectLocation := ""
-		if location != url {
-			redirectLocation = location
-		}
+	return
+}
+
+// save saves result of job unless it is filtered out
+func (f *Fuzzer) save(j job, r *response) {
+	isSaved, matched := f.filterResult(r)
+	if !isSaved {
+		return
+	}
 
-		size := len(res)
+	f.statsQueue <- "saved"
+
+	f.recurse(j, r)
+
+	f.results <- Result{
+		RedirectLocation: r.RedirectLocation,
+		URL:              j.URL,
+		Method:           j.Method,
+		Host:             j.Headers.Get("Host"),
+		Input:            j.Input,
+		Payload:          j.Payload,
+		Rule:             j.Rule,
+		Extension:        j.Extension,
+		Depth:            j.depth,
+		Size:             r.Size,
+		Lines:            r.Lines,
+		StatusCode:       r.StatusCode,
+		Words:            r.Words,
+		Matched:          matched,
+	}
+}
 
-		f.statsQueue <- "processed"
+// execute sends request defined by job, name is resolved in dns scan
+func (f *Fuzzer) execute(ctx context.Context, j job) (r *response, err error) {
+	if f.ScanType == ScanTypeDNS {
+		return f.lookup(ctx, j)
+	}
+
+	url := j.URL
+	headers := request.GetHeaders()
+	for name, values := range j.Headers {
+		headers[name] = values
+	}
 
-		if !f.filterResult(lines, words, size, statusCode) {
-			continue
+	if headers.Get("user-agent") == "" {
+		ua := request.GetUserAgent(f.UserAgent, f.PseudoRandomUserAgent)
+		headers["user-agent"] = []string{
+			ua,
 		}
+	}
+
+	if f.PreExecuteRequestTransform != nil {
+		(f.PreExecuteRequestTransform)(&url, &f.ProxyURL, &headers)
+	}
 
-		f.statsQueue <- "saved"
+	res, statusCode, location, responseHeaders, err := f.requester.Do(ctx, url, j.Method, j.Body, headers)
+	if err != nil {
+		return
+	}
 
-		f.results <- Result{
-			RedirectLocation: redirectLocation,
-			URL:              j.URL,
-			Size:             size,
-			Lines:            lines,
-			StatusCode:       statusCode,
-			Words:            words,
-		}
+	r = newResponse(res, statusCode, responseHeaders)
+	if location != url {
+		r.RedirectLocation = location
 	}
+
+	return
 }
@@ -0,0 +1,225 @@
+// Package dnstest provides DNS server for tests, it answers A queries from
+// static records over UDP and TCP on the same address
+package dnstest
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	typeA = 1
+
+	rcodeNameError = 3
+)
+
+// Server answers A queries of records, name *.example.com is wildcard record,
+// other names don't exist
+type Server struct {
+	// Addr is address of server, ip:port, for both UDP and TCP
+	Addr string
+
+	records map[string]string
+
+	udp *net.UDPConn
+	tcp *net.TCPListener
+
+	udpQueries int32
+	tcpQueries int32
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts server of records, name -> IPv4 address
+func NewServer(records map[string]string) (s *Server, err error) {
+	s = &Server{
+		records: make(map[string]string, len(records)),
+	}
+
+	for name, ip := range records {
+		s.records[strings.ToLower(strings.TrimSuffix(name, "."))] = ip
+	}
+
+	// TCP listens on port chosen for UDP, which can be taken
+	for attempt := 0; attempt < 10; attempt++ {
+		s.udp, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			return
+		}
+
+		addr := s.udp.LocalAddr().(*net.UDPAddr)
+		s.tcp, err = net.ListenTCP("tcp", &net.TCPAddr{IP: addr.IP, Port: addr.Port})
+		if err == nil {
+			s.Addr = addr.String()
+			break
+		}
+		s.udp.Close()
+	}
+
+	if err != nil {
+		return
+	}
+
+	s.wg.Add(2)
+	go s.serveUDP()
+	go s.serveTCP()
+
+	return
+}
+
+// Queries returns number of queries received over network, udp or tcp
+func (s *Server) Queries(network string) int {
+	if network == "tcp" {
+		return int(atomic.LoadInt32(&s.tcpQueries))
+	}
+
+	return int(atomic.LoadInt32(&s.udpQueries))
+}
+
+// Close stops server
+func (s *Server) Close() {
+	s.udp.Close()
+	s.tcp.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := s.udp.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		atomic.AddInt32(&s.udpQueries, 1)
+		res, err := s.answer(buf[:n])
+		if err != nil {
+			continue
+		}
+		s.udp.WriteToUDP(res, addr)
+	}
+}
+
+func (s *Server) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			// messages are prefixed by length
+			for {
+				var size uint16
+				err := binary.Read(conn, binary.BigEndian, &size)
+				if err != nil {
+					return
+				}
+
+				query := make([]byte, size)
+				_, err = io.ReadFull(conn, query)
+				if err != nil {
+					return
+				}
+
+				atomic.AddInt32(&s.tcpQueries, 1)
+				res, err := s.answer(query)
+				if err != nil {
+					return
+				}
+
+				res = append([]byte{byte(len(res) >> 8), byte(len(res))}, res...)
+				_, err = conn.Write(res)
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// lookup finds address of name, exact record is preferred over wildcard
+func (s *Server) lookup(name string) (ip string, ok bool) {
+	ip, ok = s.records[name]
+	if ok {
+		return
+	}
+
+	for i := strings.Index(name, "."); i >= 0; i = strings.Index(name, ".") {
+		name = name[i+1:]
+		ip, ok = s.records["*."+name]
+		if ok {
+			return
+		}
+	}
+
+	return
+}
+
+// answer builds response to query with single question
+func (s *Server) answer(query []byte) (res []byte, err error) {
+	if len(query) < 12 {
+		err = errors.New("short query")
+		return
+	}
+
+	// question name is sequence of labels terminated by empty label
+	labels := make([]string, 0)
+	i := 12
+	for i < len(query) && query[i] != 0 {
+		size := int(query[i])
+		if i+1+size > len(query) {
+			err = errors.New("invalid question")
+			return
+		}
+		labels = append(labels, string(query[i+1:i+1+size]))
+		i += 1 + size
+	}
+
+	end := i + 5
+	if end > len(query) {
+		err = errors.New("invalid question")
+		return
+	}
+	qtype := binary.BigEndian.Uint16(query[i+1 : i+3])
+
+	ip, ok := s.lookup(strings.ToLower(strings.Join(labels, ".")))
+
+	flags := uint16(0x8180)
+	if !ok {
+		flags |= rcodeNameError
+	}
+
+	answers := uint16(0)
+	if ok && qtype == typeA {
+		answers = 1
+	}
+
+	res = make([]byte, 12, end+16)
+	copy(res, query[:2])
+	binary.BigEndian.PutUint16(res[2:], flags)
+	binary.BigEndian.PutUint16(res[4:], 1)
+	binary.BigEndian.PutUint16(res[6:], answers)
+	res = append(res, query[12:end]...)
+
+	if answers > 0 {
+		// name points to question, class IN, TTL 60
+		res = append(res, 0xc0, 12, 0, typeA, 0, 1, 0, 0, 0, 60, 0, 4)
+		res = append(res, net.ParseIP(ip).To4()...)
+	}
+
+	return
+}
@@ -1,11 +1,40 @@
 package request
 
-import "net/http"
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
 
 func GetHeaders() (headers http.Header) {
 	return http.Header{}
 }
 
+// ParseHeader parses header in format "Name: value"
+func ParseHeader(input string) (name, value string, err error) {
+	idx := strings.Index(input, ":")
+	if idx <= 0 {
+		err = errors.New("header must be in format \"Name: value\"")
+		return
+	}
+
+	name = strings.TrimSpace(input[:idx])
+	value = strings.TrimSpace(input[idx+1:])
+	return
+}
+
+// BasicAuth returns value of Authorization header for basic auth credentials
+// in format user:password
+func BasicAuth(credentials string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
+
+// BearerAuth returns value of Authorization header for bearer token
+func BearerAuth(token string) string {
+	return "Bearer " + token
+}
+
 func DefaultTransform(jobURL *string, proxyURL *string, headers *http.Header) {
 	(*headers)["Target"] = []string{
 		*jobURL,
@@ -0,0 +1,116 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// skippedRawHeaders are headers of raw request which are set by HTTP client,
+// Content-Length changes with substituted body and Accept-Encoding would turn
+// off transparent decompression of response
+var skippedRawHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"accept-encoding":   true,
+	"connection":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+}
+
+// Raw is HTTP/1.1 request captured by intercepting proxy, it can contain
+// keywords anywhere
+type Raw struct {
+	Method string
+
+	// Target is request target, path or absolute URL
+	Target string
+
+	// Host is value of Host header
+	Host string
+
+	// Headers are not canonicalized, since names can contain keywords
+	Headers http.Header
+
+	Body string
+}
+
+// ParseRaw parses raw HTTP/1.1 request, request line, headers and body
+func ParseRaw(raw string) (r Raw, err error) {
+	raw = strings.TrimLeft(raw, "\r\n")
+
+	// headers are separated from body by empty line
+	head, body, found := strings.Cut(raw, "\r\n\r\n")
+	if !found {
+		head, body, _ = strings.Cut(raw, "\n\n")
+	}
+	r.Body = body
+
+	lines := strings.Split(strings.ReplaceAll(head, "\r\n", "\n"), "\n")
+
+	parts := strings.Fields(lines[0])
+	if len(parts) < 2 || len(parts) > 3 {
+		err = fmt.Errorf("invalid request line %q", lines[0])
+		return
+	}
+	r.Method = parts[0]
+	r.Target = parts[1]
+
+	r.Headers = http.Header{}
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+
+		var name, value string
+		name, value, err = ParseHeader(line)
+		if err != nil {
+			err = fmt.Errorf("invalid header %q: %w", line, err)
+			return
+		}
+
+		if strings.EqualFold(name, "Host") {
+			r.Host = value
+		}
+
+		if skippedRawHeaders[strings.ToLower(name)] {
+			continue
+		}
+
+		r.Headers[name] = append(r.Headers[name], value)
+	}
+
+	return
+}
+
+// URL builds URL of request from target, scheme and host are used if target
+// is not absolute URL, host overrides Host header if set
+func (r *Raw) URL(scheme, host string) (address string, err error) {
+	if strings.HasPrefix(r.Target, "http://") || strings.HasPrefix(r.Target, "https://") {
+		address = r.Target
+		return
+	}
+
+	if host == "" {
+		host = r.Host
+	}
+
+	if host == "" {
+		err = errors.New("host is not defined, request has no Host header")
+		return
+	}
+
+	if !strings.HasPrefix(r.Target, "/") {
+		err = fmt.Errorf("invalid request target %s", r.Target)
+		return
+	}
+
+	u := url.URL{
+		Scheme: scheme,
+		Host:   host,
+	}
+	address = u.String() + r.Target
+	return
+}
@@ -0,0 +1,146 @@
+package request
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRaw(t *testing.T) {
+	post := "POST /login?next=FUZZ HTTP/1.1\n" +
+		"Host: example.com\n" +
+		"Content-Type: application/x-www-form-urlencoded\n" +
+		"Content-Length: 27\n" +
+		"Accept-Encoding: gzip, deflate\n" +
+		"Connection: keep-alive\n" +
+		"X-FUZZ: FUZZ\n" +
+		"Cookie: a=1\n" +
+		"Cookie: b=2\n" +
+		"\n" +
+		"user=admin&pass=FUZZ\n\nend"
+
+	tests := []struct {
+		name string
+		raw  string
+		want Raw
+	}{
+		{"LF", post, Raw{
+			Method: "POST",
+			Target: "/login?next=FUZZ",
+			Host:   "example.com",
+			Headers: http.Header{
+				"Content-Type": {"application/x-www-form-urlencoded"},
+				"X-FUZZ":       {"FUZZ"},
+				"Cookie":       {"a=1", "b=2"},
+			},
+			Body: "user=admin&pass=FUZZ\n\nend",
+		}},
+		{"CRLF", strings.ReplaceAll(post, "\n", "\r\n"), Raw{
+			Method: "POST",
+			Target: "/login?next=FUZZ",
+			Host:   "example.com",
+			Headers: http.Header{
+				"Content-Type": {"application/x-www-form-urlencoded"},
+				"X-FUZZ":       {"FUZZ"},
+				"Cookie":       {"a=1", "b=2"},
+			},
+			Body: "user=admin&pass=FUZZ\r\n\r\nend",
+		}},
+
+		// names aren't canonicalized, dropped headers are case insensitive
+		{"names", "GET /FUZZ HTTP/1.1\r\nhost: example.com\r\ncontent-length: 0\r\nx-api-key: FUZZ\r\n\r\n", Raw{
+			Method:  "GET",
+			Target:  "/FUZZ",
+			Host:    "example.com",
+			Headers: http.Header{"x-api-key": {"FUZZ"}},
+		}},
+		{"absolute", "GET http://example.com:8080/FUZZ HTTP/1.1\n\n", Raw{
+			Method:  "GET",
+			Target:  "http://example.com:8080/FUZZ",
+			Headers: http.Header{},
+		}},
+		{"leading lines", "\r\n\r\nFUZZ / HTTP/1.1\r\nHost: FUZZ.example.com", Raw{
+			Method:  "FUZZ",
+			Target:  "/",
+			Host:    "FUZZ.example.com",
+			Headers: http.Header{},
+		}},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRaw(tt.raw)
+		if err != nil {
+			t.Errorf("%s: error %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(r, tt.want) {
+			t.Errorf("%s: ParseRaw() = %+v, want %+v", tt.name, r, tt.want)
+		}
+	}
+}
+
+func TestParseRawErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"GET\r\nHost: example.com\r\n\r\n",
+		"GET / HTTP/1.1 extra\r\nHost: example.com\r\n\r\n",
+		"GET / HTTP/1.1\r\nHost example.com\r\n\r\n",
+		"GET / HTTP/1.1\r\n: value\r\n\r\n",
+	}
+
+	for _, raw := range tests {
+		_, err := ParseRaw(raw)
+		if err == nil {
+			t.Errorf("ParseRaw(%q) expected error", raw)
+		}
+	}
+}
+
+func TestRawURL(t *testing.T) {
+	tests := []struct {
+		target string
+		header string
+		scheme string
+		host   string
+		want   string
+		isErr  bool
+	}{
+		{"/admin/FUZZ?a=1", "example.com", "https", "", "https://example.com/admin/FUZZ?a=1", false},
+		{"/", "example.com", "http", "", "http://example.com/", false},
+
+		// host argument overrides Host header
+		{"/FUZZ", "example.com", "https", "127.0.0.1:8080", "https://127.0.0.1:8080/FUZZ", false},
+		{"/", "FUZZ.example.com", "https", "", "https://FUZZ.example.com/", false},
+
+		// absolute form is used as it is
+		{"http://other.com/FUZZ", "example.com", "https", "127.0.0.1", "http://other.com/FUZZ", false},
+		{"https://other.com/", "", "http", "", "https://other.com/", false},
+
+		{"/FUZZ", "", "https", "", "", true},
+		{"FUZZ", "example.com", "https", "", "", true},
+		{"*", "example.com", "https", "", "", true},
+	}
+
+	for _, tt := range tests {
+		r := Raw{Target: tt.target, Host: tt.header}
+
+		address, err := r.URL(tt.scheme, tt.host)
+		if tt.isErr {
+			if err == nil {
+				t.Errorf("URL(%s, %s) of %s expected error", tt.scheme, tt.host, tt.target)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("URL(%s, %s) of %s error: %v", tt.scheme, tt.host, tt.target, err)
+			continue
+		}
+
+		if address != tt.want {
+			t.Errorf("URL(%s, %s) of %s = %s, want %s", tt.scheme, tt.host, tt.target, address, tt.want)
+		}
+	}
+}
@@ -4,11 +4,11 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"errors"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/dpanic/fuzzer/src/logger"
@@ -16,40 +16,121 @@ import (
 	"go.uber.org/zap"
 )
 
-var (
-	client  *http.Client
-	timeout = 20 * time.Second
+const (
+	DefaultTimeout             = 20 * time.Second
+	DefaultDialTimeout         = 10 * time.Second
+	DefaultTLSHandshakeTimeout = 5 * time.Second
+	DefaultMaxReadSize         = 1 << 20
 )
 
-func Setup(proxyURL string) {
+// Options defines settings of HTTP client, zero values are replaced by defaults
+type Options struct {
+	// ProxyURL defines HTTP forwarding proxy if set
+	ProxyURL string
+
+	// Timeout defines maximum duration of whole request
+	Timeout time.Duration
+
+	// DialTimeout defines maximum duration of establishing TCP connection
+	DialTimeout time.Duration
+
+	// TLSHandshakeTimeout defines maximum duration of TLS handshake
+	TLSHandshakeTimeout time.Duration
+
+	// MaxReadSize defines maximum number of bytes read from response body,
+	// larger bodies are truncated without error
+	MaxReadSize int
+
+	// TLSConfig defines TLS settings, by default certificates are not verified
+	TLSConfig *tls.Config
+
+	// Resolver resolves hosts of requests, system resolver is used if not set
+	Resolver *Resolver
+
+	// Log defines logger, default logger is used if not set
+	Log *zap.Logger
+}
+
+func (o *Options) setDefaults() {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = DefaultDialTimeout
+	}
+
+	if o.TLSHandshakeTimeout <= 0 {
+		o.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+
+	if o.MaxReadSize <= 0 {
+		o.MaxReadSize = DefaultMaxReadSize
+	}
+
+	if o.TLSConfig == nil {
+		o.TLSConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+	}
+
+	if o.Log == nil {
+		o.Log = logger.Log
+	}
+}
+
+// Requester sends HTTP requests with its own client, so multiple fuzzers with
+// different settings can run in the same process
+type Requester struct {
+	client      *http.Client
+	timeout     time.Duration
+	maxReadSize int
+	log         *zap.Logger
+}
+
+// New creates requester with own transport configured by options
+func New(options Options) (r *Requester) {
 	var proxy func(*http.Request) (*url.URL, error)
 
-	if proxyURL != "" {
-		fixedURL, _ := url.Parse(proxyURL)
+	options.setDefaults()
+
+	if options.ProxyURL != "" {
+		fixedURL, _ := url.Parse(options.ProxyURL)
 		proxy = http.ProxyURL(fixedURL)
-		logger.Log.Debug("proxy url is set",
-			zap.String("proxyURL", proxyURL),
+		options.Log.Debug("proxy url is set",
+			zap.String("proxyURL", options.ProxyURL),
 		)
 	}
 
-	client = &http.Client{
-		Timeout: timeout,
+	r = &Requester{
+		timeout:     options.Timeout,
+		maxReadSize: options.MaxReadSize,
+		log:         options.Log,
+	}
+
+	var resolver *net.Resolver
+	if options.Resolver != nil {
+		resolver = options.Resolver.resolver
+	}
+
+	r.client = &http.Client{
+		Timeout: options.Timeout,
 		Transport: &http.Transport{
 			ForceAttemptHTTP2: true,
 			Proxy:             proxy,
 			DialContext: (&net.Dialer{
-				Timeout: 10 * time.Second,
+				Timeout:  options.DialTimeout,
+				Resolver: resolver,
 			}).DialContext,
 
 			Dial: (&net.Dialer{
-				Timeout:   10 * time.Second,
+				Timeout:   options.DialTimeout,
 				KeepAlive: 5 * time.Second,
+				Resolver:  resolver,
 			}).Dial,
 
-			TLSHandshakeTimeout: 5 * time.Second,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
+			TLSHandshakeTimeout: options.TLSHandshakeTimeout,
+			TLSClientConfig:     options.TLSConfig,
 
 			DisableKeepAlives:   false,
 			MaxIdleConns:        1000,
@@ -57,14 +138,14 @@ func Setup(proxyURL string) {
 			MaxConnsPerHost:     500,
 		},
 	}
-}
 
-const (
-	maxReadSize = 1 << 20
-)
+	return
+}
 
-func Do(address, method string, body []byte, headers http.Header, customLogger *zap.Logger) (result []byte, statusCode int, location string, err error) {
-	log := customLogger.WithOptions(zap.Fields(
+// Do sends request and reads response body up to max read size, request is
+// aborted when ctx is canceled
+func (r *Requester) Do(ctx context.Context, address, method string, body []byte, headers http.Header) (result []byte, statusCode int, location string, responseHeaders http.Header, err error) {
+	log := r.log.WithOptions(zap.Fields(
 		zap.String("address", address),
 		zap.String("method", method),
 		zap.Any("headers", headers),
@@ -72,7 +153,7 @@ func Do(address, method string, body []byte, headers http.Header, customLogger *
 	),
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Duration(2)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout+time.Duration(2)*time.Second)
 	defer cancel()
 
 	httpRequest, err := http.NewRequestWithContext(ctx, method, address, bytes.NewBuffer(body))
@@ -86,6 +167,13 @@ func Do(address, method string, body []byte, headers http.Header, customLogger *
 
 	if headers != nil {
 		httpRequest.Header = headers
+
+		// Go ignores Host header, so virtual host is set on request
+		for name, values := range headers {
+			if strings.EqualFold(name, "Host") && len(values) > 0 {
+				httpRequest.Host = values[0]
+			}
+		}
 	}
 
 	if err != nil {
@@ -96,7 +184,7 @@ func Do(address, method string, body []byte, headers http.Header, customLogger *
 		return
 	}
 
-	resp, err := client.Do(httpRequest)
+	resp, err := r.client.Do(httpRequest)
 	if err != nil {
 		if resp != nil && resp.StatusCode != http.StatusNotFound {
 			log.Warn("error in client",
@@ -127,12 +215,12 @@ func Do(address, method string, body []byte, headers http.Header, customLogger *
 		n, err = resp.Body.Read(buf)
 
 		if n > 0 {
-			buf = buf[:n]
-			result = append(result, buf...)
+			result = append(result, buf[:n]...)
 
-			if len(result) > maxReadSize {
-				result = result[:maxReadSize]
-				err = errors.New("file limit has reached")
+			// body is truncated to max read size, it isn't an error
+			if len(result) > r.maxReadSize {
+				result = result[:r.maxReadSize]
+				err = nil
 				break
 			}
 		}
@@ -152,6 +240,7 @@ func Do(address, method string, body []byte, headers http.Header, customLogger *
 
 	if resp != nil {
 		location = resp.Request.URL.String()
+		responseHeaders = resp.Header
 		// status = resp.Status
 		statusCode = resp.StatusCode
 	}
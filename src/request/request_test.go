@@ -0,0 +1,61 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRequesterMaxReadSize(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 500)
+
+	// body is written in small chunks, so reads are shorter than buffer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < len(body); i += 7 {
+			end := i + 7
+			if end > len(body) {
+				end = len(body)
+			}
+
+			w.Write(body[i:end])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		maxReadSize int
+		length      int
+	}{
+		{10000, len(body)},
+		{len(body), len(body)},
+		{3000, 3000},
+		{1, 1},
+	}
+
+	for _, tt := range tests {
+		r := New(Options{
+			MaxReadSize: tt.maxReadSize,
+			Log:         zap.NewNop(),
+		})
+
+		result, statusCode, _, _, err := r.Do(context.Background(), server.URL, http.MethodGet, nil, nil)
+		if err != nil {
+			t.Errorf("max %d: error %v", tt.maxReadSize, err)
+			continue
+		}
+
+		if statusCode != http.StatusOK {
+			t.Errorf("max %d: status %d", tt.maxReadSize, statusCode)
+		}
+
+		// larger bodies are truncated without error
+		if !bytes.Equal(result, body[:tt.length]) {
+			t.Errorf("max %d: read %d bytes, want first %d bytes of body", tt.maxReadSize, len(result), tt.length)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultDNSPort is port of resolver if address has no port
+	DefaultDNSPort = "53"
+
+	// ResolverProtocolUDP queries resolvers over UDP, truncated answers are
+	// repeated over TCP
+	ResolverProtocolUDP = "udp"
+
+	// ResolverProtocolTCP queries resolvers over TCP only
+	ResolverProtocolTCP = "tcp"
+)
+
+// Resolver resolves names with custom DNS servers, servers are used round-robin,
+// system resolver is used if servers are not defined
+type Resolver struct {
+	servers  []string
+	protocol string
+	timeout  time.Duration
+	next     uint32
+
+	resolver *net.Resolver
+}
+
+// NewResolver creates resolver of servers in format ip or ip:port, protocol
+// is udp or tcp, udp by default
+func NewResolver(servers []string, protocol string, timeout time.Duration) (r *Resolver, err error) {
+	switch protocol {
+	case "":
+		protocol = ResolverProtocolUDP
+	case ResolverProtocolUDP, ResolverProtocolTCP:
+	default:
+		err = fmt.Errorf("unknown resolver protocol %s", protocol)
+		return
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+
+	r = &Resolver{
+		protocol: protocol,
+		timeout:  timeout,
+		resolver: net.DefaultResolver,
+	}
+
+	for _, server := range servers {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+
+		if _, _, e := net.SplitHostPort(server); e != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), DefaultDNSPort)
+		}
+
+		host, _, _ := net.SplitHostPort(server)
+		if net.ParseIP(host) == nil {
+			err = fmt.Errorf("invalid resolver %s, IP address is expected", server)
+			return
+		}
+
+		r.servers = append(r.servers, server)
+	}
+
+	if len(r.servers) > 0 {
+		r.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial:     r.dial,
+		}
+	}
+
+	return
+}
+
+// ParseResolvers parses comma separated resolvers, 1.1.1.1,8.8.8.8:53
+func ParseResolvers(input string) (servers []string) {
+	for _, s := range strings.Split(input, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		servers = append(servers, s)
+	}
+
+	return
+}
+
+// dial connects to next server, address chosen by Go resolver is ignored
+func (r *Resolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	n := atomic.AddUint32(&r.next, 1) - 1
+	server := r.servers[int(n%uint32(len(r.servers)))]
+
+	if r.protocol == ResolverProtocolTCP {
+		network = "tcp"
+	}
+
+	d := net.Dialer{
+		Timeout: r.timeout,
+	}
+	return d.DialContext(ctx, network, server)
+}
+
+// LookupHost resolves name to its addresses, names which don't exist have
+// no addresses and no error
+func (r *Resolver) LookupHost(ctx context.Context, name string) (addresses []string, err error) {
+	addresses, err = r.resolver.LookupHost(ctx, name)
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		addresses, err = nil, nil
+	}
+
+	return
+}
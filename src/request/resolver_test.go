@@ -0,0 +1,134 @@
+package request
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dpanic/fuzzer/src/request/dnstest"
+)
+
+func newTestServer(t *testing.T) *dnstest.Server {
+	t.Helper()
+
+	s, err := dnstest.NewServer(map[string]string{
+		"www.fz.test":  "10.0.0.1",
+		"mail.fz.test": "10.0.0.2",
+		"*.wild.test":  "10.9.9.9",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestResolverLookupHost(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, protocol := range []string{ResolverProtocolUDP, ResolverProtocolTCP} {
+		r, err := NewResolver([]string{s.Addr}, protocol, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name      string
+			addresses []string
+		}{
+			{"www.fz.test", []string{"10.0.0.1"}},
+			{"mail.fz.test", []string{"10.0.0.2"}},
+			{"anything.wild.test", []string{"10.9.9.9"}},
+
+			// NXDOMAIN is not an error
+			{"nope.fz.test", nil},
+		}
+
+		for _, tt := range tests {
+			addresses, err := r.LookupHost(context.Background(), tt.name)
+			if err != nil {
+				t.Errorf("%s: LookupHost(%s) error: %v", protocol, tt.name, err)
+				continue
+			}
+
+			if !reflect.DeepEqual(addresses, tt.addresses) {
+				t.Errorf("%s: LookupHost(%s) = %v, want %v", protocol, tt.name, addresses, tt.addresses)
+			}
+		}
+	}
+}
+
+func TestResolverProtocolTCP(t *testing.T) {
+	s := newTestServer(t)
+
+	r, err := NewResolver([]string{s.Addr}, ResolverProtocolTCP, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.LookupHost(context.Background(), "www.fz.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Queries("udp") != 0 || s.Queries("tcp") == 0 {
+		t.Errorf("queries udp %d, tcp %d, want only tcp", s.Queries("udp"), s.Queries("tcp"))
+	}
+}
+
+func TestResolverRoundRobin(t *testing.T) {
+	a, b := newTestServer(t), newTestServer(t)
+
+	r, err := NewResolver([]string{a.Addr, b.Addr}, ResolverProtocolUDP, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err = r.LookupHost(context.Background(), "www.fz.test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// every lookup sends A and AAAA query, each over new connection
+	if a.Queries("udp") != b.Queries("udp") || a.Queries("udp") == 0 {
+		t.Errorf("queries %d and %d, want the same number on both servers", a.Queries("udp"), b.Queries("udp"))
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		servers  []string
+		protocol string
+		want     []string
+		isErr    bool
+	}{
+		{[]string{"1.1.1.1", "8.8.8.8:5353"}, "", []string{"1.1.1.1:53", "8.8.8.8:5353"}, false},
+		{[]string{"::1", "[::1]:5353"}, "tcp", []string{"[::1]:53", "[::1]:5353"}, false},
+		{[]string{" 1.1.1.1 ", ""}, "udp", []string{"1.1.1.1:53"}, false},
+		{[]string{"dns.google"}, "", nil, true},
+		{[]string{"1.1.1.1"}, "doh", nil, true},
+	}
+
+	for _, tt := range tests {
+		r, err := NewResolver(tt.servers, tt.protocol, 0)
+		if tt.isErr {
+			if err == nil {
+				t.Errorf("NewResolver(%v, %q) expected error", tt.servers, tt.protocol)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("NewResolver(%v, %q) error: %v", tt.servers, tt.protocol, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(r.servers, tt.want) {
+			t.Errorf("NewResolver(%v, %q) servers = %v, want %v", tt.servers, tt.protocol, r.servers, tt.want)
+		}
+	}
+}